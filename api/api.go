@@ -1,13 +1,10 @@
 package api
 
 import (
-	"fmt"
+	gocontext "context"
 
 	"github.com/botscubes/bot-components/context"
-	"github.com/botscubes/bql/internal/evaluator"
-	"github.com/botscubes/bql/internal/lexer"
 	"github.com/botscubes/bql/internal/object"
-	"github.com/botscubes/bql/internal/parser"
 )
 
 // code - код
@@ -23,37 +20,78 @@ import (
 //
 // пример работы есть в файле internal/app/app.go (func prepareCtx()), код в input.txt (запуск: make start)
 //
-// результат - значение нативного типа Golang и ошибка, если она есть. Если ошибки нет - nil
+// если один и тот же код выполняется много раз, лучше один раз скомпилировать его через Compile
+// и затем вызывать Program.Run
+//
+// значения контекста: числа, строки, bool, nil, time.Time, time.Duration, *big.Rat, срезы, map и структуры
+// (дробные числа без дробной части становятся целыми, т.к. контекст хранится в JSON)
+//
+// результат - значение нативного типа Golang и ошибка, если она есть. Если ошибки нет - nil.
+// Целое число - int64, дробное - float64, десятичное - *big.Rat, массив - []any, hash map - map[string]any
+// (ключи преобразуются в строку, hash map с ключами 1 и "1" - ошибка).
+// Ошибки в коде скрипта (lex, parse, runtime) возвращаются как *Error со списком Diagnostics,
+// в котором есть позиция ошибки (строка, символ) для подсветки в редакторе
 
 func EvalWithCtx(code string, ctx *context.Context, passVars *[]string) (any, error) {
-	l := lexer.New(code)
-
-	p := parser.New(l)
-	program := p.ParseProgram()
-	if len(p.Errors()) != 0 {
-		var errorslist string
-		for _, e := range p.Errors() {
-			errorslist += e
-		}
-		return nil, fmt.Errorf("%s", errorslist)
-	}
-
-	env := object.NewEnv()
+	return EvalWithLimits(gocontext.Background(), code, ctx, passVars, DefaultLimits)
+}
 
-	env, err := object.ConvertContextToEnv(ctx, env, passVars)
+// то же, что EvalWithCtx, но дополнительно возвращает итоговые значения переменных outVars
+// после выполнения кода (см. Program.RunWithVars), например, чтобы сохранить их в контекст бота:
+//
+//	code: count = count + 1
+//	_, vars, err := api.EvalWithVars(code, botCtx, &[]string{"count"}, []string{"count"})
+//	// vars["count"] - новое значение счетчика
+func EvalWithVars(code string, ctx *context.Context, passVars *[]string, outVars []string) (any, map[string]any, error) {
+	p, err := Compile(code)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	ev := evaluator.Eval(program, env)
-	if ev != nil {
-		v, ok := object.ExtractRawValueFromObject(ev)
-		if !ok {
-			return nil, fmt.Errorf("%s", v)
-		}
+	return p.RunWithVars(gocontext.Background(), ctx, passVars, outVars)
+}
+
+// ограничения выполнения скрипта. Нулевое значение поля - без ограничения, кроме MaxCallDepth.
+//
+// MaxSteps     - максимальное количество шагов: вычисленных узлов AST в EvaluatorBackend,
+// выполненных инструкций в VMBackend (один и тот же скрипт делает разное количество шагов)
+// MaxCallDepth - максимальная глубина вызовов функций, 0 - DefaultMaxCallDepth, отрицательное значение - без ограничения
+// MaxAllocSize - максимальная длина строки (в байтах), массива или hash map,
+// для конкатенации строк, шаблонных строк и функций repeat, padLeft, padRight, replace, replaceRegex, join,
+// format, jsonStringify, concat, range проверяется до создания значения
+// CheckOverflow - переполнение целого числа в операторах (+, -, *, /) - ошибка выполнения,
+// без этого значение переполняется (как int64 в Go)
+type Limits = object.Limits
+
+// возвращается, если выполнение остановлено по ограничению или по ctx (deadline, cancel).
+// Поле Limit - какое ограничение превышено: "STEPS", "CALL_DEPTH", "ALLOC_SIZE", "TIMEOUT"
+type LimitError = object.LimitError
+
+// глубина вызовов, если Limits.MaxCallDepth равно 0, защищает от переполнения стека при бесконечной рекурсии
+const DefaultMaxCallDepth = object.DefaultMaxCallDepth
+
+// ограничения для EvalWithCtx
+var DefaultLimits = Limits{
+	MaxCallDepth: DefaultMaxCallDepth,
+}
 
-		return v, nil
+// то же, что EvalWithCtx, но выполнение ограничено limits и ctx (deadline, cancel).
+// При превышении ограничения возвращается ошибка *LimitError
+//
+// пример:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+//	defer cancel()
+//	result, err := api.EvalWithLimits(ctx, code, botCtx, &passVars, api.Limits{
+//		MaxSteps:     100000,
+//		MaxCallDepth: 100,
+//		MaxAllocSize: 10000,
+//	})
+func EvalWithLimits(ctx gocontext.Context, code string, botCtx *context.Context, passVars *[]string, limits Limits) (any, error) {
+	p, err := Compile(code, Config{Limits: limits})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("eval return null")
+	return p.Run(ctx, botCtx, passVars)
 }
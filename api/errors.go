@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// вид ошибки
+const (
+	LexError     = "lex"     // недопустимый символ
+	ParseError   = "parse"   // синтаксическая ошибка
+	CompileError = "compile" // код превышает ограничения байткода (VMBackend)
+	RuntimeError = "runtime" // ошибка выполнения
+)
+
+// ошибка в коде скрипта
+type Diagnostic struct {
+	Kind    string // LexError, ParseError, CompileError, RuntimeError
+	Line    int    // номер строки, начиная с 1. 0 - позиция неизвестна
+	Column  int    // номер символа в строке, начиная с 1. 0 - позиция неизвестна
+	Snippet string // строка кода, в которой находится ошибка
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s error: %s", d.Kind, d.Message)
+	}
+
+	return fmt.Sprintf("%s error: %d:%d: %s", d.Kind, d.Line, d.Column, d.Message)
+}
+
+// возвращается из Compile (ошибки lex и parse) и Run (ошибка runtime).
+// Ошибки отсортированы по позиции в коде
+type Error struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *Error) Error() string {
+	list := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		list[i] = d.String()
+	}
+
+	return strings.Join(list, "\n")
+}
+
+// line, column - начиная с 1
+func newDiagnostic(code string, kind string, line int, column int, message string) Diagnostic {
+	return Diagnostic{
+		Kind:    kind,
+		Line:    line,
+		Column:  column,
+		Snippet: sourceLine(code, line),
+		Message: message,
+	}
+}
+
+func newError(diagnostics []Diagnostic) *Error {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+
+	return &Error{Diagnostics: diagnostics}
+}
+
+// returns line of code by number (starting from 1)
+func sourceLine(code string, line int) string {
+	if line < 1 {
+		return ""
+	}
+
+	lines := strings.Split(code, "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimRight(lines[line-1], "\r")
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompileDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Diagnostic
+	}{
+		{"x = 1 $ 2", []Diagnostic{
+			{Kind: LexError, Line: 1, Column: 7, Snippet: "x = 1 $ 2", Message: `illegal character: "$"`},
+		}},
+		{"x = 1\ny = \"abc", []Diagnostic{
+			{Kind: LexError, Line: 2, Column: 5, Snippet: `y = "abc`, Message: "unterminated string"},
+		}},
+		{"x = 1\ny = (2 + 3", []Diagnostic{
+			{Kind: ParseError, Line: 2, Column: 11, Snippet: "y = (2 + 3", Message: "expected next token: ), got EOF"},
+		}},
+		{"x = 1\r\n  y = 1 2", []Diagnostic{
+			{Kind: ParseError, Line: 2, Column: 9, Snippet: "  y = 1 2", Message: "expected ; at end of statement, got 2"},
+		}},
+		// errors of lexer and parser are sorted by position
+		{"a = (1\nb = 2 @", []Diagnostic{
+			{Kind: ParseError, Line: 1, Column: 7, Snippet: "a = (1", Message: "expected next token: ), got ;"},
+			{Kind: LexError, Line: 2, Column: 7, Snippet: "b = 2 @", Message: `illegal character: "@"`},
+		}},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.input)
+
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("error is not *Error: %v in test: %q", err, test.input)
+			continue
+		}
+
+		if !reflect.DeepEqual(e.Diagnostics, test.expected) {
+			t.Errorf("wrong diagnostics in test: %q\ngot:      %+v\nexpected: %+v", test.input, e.Diagnostics, test.expected)
+		}
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	_, err := Compile("x = 1\ny = (2 + 3")
+	if err == nil || err.Error() != "parse error: 2:11: expected next token: ), got EOF" {
+		t.Errorf("wrong error text: %v", err)
+	}
+
+	d := Diagnostic{Kind: RuntimeError, Message: "division by zero"}
+	if d.String() != "runtime error: division by zero" {
+		t.Errorf("wrong text of diagnostic without position: %s", d.String())
+	}
+}
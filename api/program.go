@@ -0,0 +1,278 @@
+package api
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/botscubes/bot-components/context"
+	"github.com/botscubes/bql/internal/ast"
+	"github.com/botscubes/bql/internal/compiler"
+	"github.com/botscubes/bql/internal/evaluator"
+	"github.com/botscubes/bql/internal/lexer"
+	"github.com/botscubes/bql/internal/object"
+	"github.com/botscubes/bql/internal/parser"
+	"github.com/botscubes/bql/internal/vm"
+)
+
+// способ выполнения скрипта
+type Backend string
+
+const (
+	EvaluatorBackend Backend = "evaluator" // обход AST, используется по умолчанию
+	VMBackend        Backend = "vm"        // компиляция в байткод и выполнение на стековой машине
+)
+
+// функции Go, которые можно вызывать из скрипта: название -> функция.
+//
+// Количество и типы аргументов берутся из сигнатуры функции, значения преобразуются автоматически.
+// Типы параметров: целые и дробные числа, string, bool, time.Time, time.Duration, *big.Rat,
+// срезы и map[string] этих типов (ключи hash map должны быть строками),
+// структуры (из hash map, имя поля берется из тега bql или json), any.
+// Первым параметром может быть context.Context - в него передается ctx из Program.Run,
+// через него можно передать данные конкретного выполнения (например, id пользователя).
+// Функция может ничего не возвращать, возвращать значение, error или значение и error.
+// Ошибка функции становится ошибкой выполнения скрипта
+//
+// пример:
+//
+//	api.Functions{
+//		"sendMessage": func(ctx context.Context, chatID int64, text string) error { ... },
+//		"userName":    func(userID int64) (string, error) { ... },
+//	}
+type Functions map[string]any
+
+type Config struct {
+	Limits    Limits
+	Backend   Backend   // пустое значение - EvaluatorBackend
+	Functions Functions // функции доступны в скрипте как встроенные, переменные из контекста бота имеют приоритет
+	Clock     Clock     // источник текущего времени для now(), nil - системное время в UTC
+
+	// зерно для random() и randomInt(): при каждом выполнении последовательность чисел одинакова (для тестов).
+	// 0 - случайная последовательность
+	RandomSeed int64
+
+	// дробные числа из контекста бота становятся DECIMAL (по кратчайшей записи: 19.99 -> 19.99d), а не FLOAT.
+	// Контекст хранится в JSON, поэтому без этой настройки денежные суммы из контекста неточны.
+	// Целые числа остаются INTEGER
+	Decimals bool
+}
+
+// возвращает текущее время для встроенной функции now().
+// Позволяет задать фиксированное время в тестах:
+//
+//	api.Config{Clock: func() time.Time { return time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC) }}
+type Clock = object.Clock
+
+// скомпилированный скрипт. Создается один раз (например, при сохранении бота) через Compile,
+// затем выполняется через Run любое количество раз с разными контекстами.
+//
+// Program не изменяется после создания, Run можно вызывать одновременно из нескольких горутин
+type Program struct {
+	code    string
+	program *ast.Program
+	limits  Limits
+
+	functions []*object.HostFunction
+	clock     Clock
+	seed      int64
+	decimals  bool
+	bytecode  *compiler.Bytecode // nil, если используется EvaluatorBackend
+}
+
+// code - код
+// c    - настройки, если не переданы - используются DefaultLimits и EvaluatorBackend
+//
+// возвращает ошибку *Error, если в коде есть синтаксические ошибки,
+// и обычную ошибку, если сигнатура функции из c.Functions не поддерживается
+func Compile(code string, c ...Config) (*Program, error) {
+	config := Config{Limits: DefaultLimits}
+	if len(c) > 0 {
+		config = c[0]
+	}
+
+	l := lexer.New(code)
+
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	var diagnostics []Diagnostic
+	for _, e := range l.Errors() {
+		diagnostics = append(diagnostics, newDiagnostic(code, LexError, e.Pos.Line, e.Pos.Offset+1, e.Message))
+	}
+	for _, e := range p.Errors() {
+		diagnostics = append(diagnostics, newDiagnostic(code, ParseError, e.Pos.Line, e.Pos.Offset+1, e.Message))
+	}
+
+	if len(diagnostics) != 0 {
+		return nil, newError(diagnostics)
+	}
+
+	prog := &Program{
+		code:     code,
+		program:  program,
+		limits:   config.Limits,
+		clock:    config.Clock,
+		seed:     config.RandomSeed,
+		decimals: config.Decimals,
+	}
+
+	for name, fn := range config.Functions {
+		hf, err := object.NewHostFunction(name, fn)
+		if err != nil {
+			return nil, err
+		}
+
+		prog.functions = append(prog.functions, hf)
+	}
+
+	switch config.Backend {
+	case EvaluatorBackend, "":
+	case VMBackend:
+		bytecode, err := compiler.Compile(program)
+		if err != nil {
+			var e compiler.Error
+			if !errors.As(err, &e) {
+				return nil, err
+			}
+
+			return nil, newError([]Diagnostic{
+				newDiagnostic(code, CompileError, e.Pos.Line, e.Pos.Offset+1, e.Message),
+			})
+		}
+
+		prog.bytecode = bytecode
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", config.Backend)
+	}
+
+	return prog, nil
+}
+
+// ctx      - выполнение останавливается, если ctx завершен (deadline, cancel)
+// botCtx   - контекст бота
+// passVars - названия переменных из контекста бота, которые будут использоваться в коде (см. EvalWithCtx)
+//
+// результат - значение нативного типа Golang и ошибка, если она есть.
+// При ошибке выполнения скрипта возвращается *Error, при превышении ограничения - *LimitError
+func (p *Program) Run(ctx gocontext.Context, botCtx *context.Context, passVars *[]string) (any, error) {
+	ev, _, err := p.exec(ctx, botCtx, passVars)
+	if err != nil {
+		return nil, err
+	}
+
+	if ev == nil {
+		return nil, fmt.Errorf("eval return null")
+	}
+
+	return extractValue(ev)
+}
+
+// то же, что Run, но дополнительно возвращает итоговые значения переменных outVars
+// (названия переменных -> значения нативного типа Golang).
+// Так скрипт может изменять состояние пользователя: счетчики, корзины и т.д.
+//
+// в outVars можно указывать переменные из passVars и переменные, которым присваивается значение в коде
+// вне функций. Переменные, которые не были объявлены, в результат не попадают.
+// Результат выполнения может быть nil, если последняя инструкция кода не возвращает значение (например, присваивание)
+//
+// пример:
+//
+//	code: count = count + 1; push(cart, item)
+//	value, vars, err := p.RunWithVars(ctx, botCtx, &[]string{"count", "cart", "item"}, []string{"count", "cart"})
+//	// vars["count"], vars["cart"] - новые значения, которые нужно сохранить в контекст бота
+func (p *Program) RunWithVars(ctx gocontext.Context, botCtx *context.Context, passVars *[]string, outVars []string) (any, map[string]any, error) {
+	ev, env, err := p.exec(ctx, botCtx, passVars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var value any
+	if ev != nil {
+		value, err = extractValue(ev)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	vars := make(map[string]any, len(outVars))
+	for _, name := range outVars {
+		obj, ok := env.Get(name)
+		if !ok {
+			continue
+		}
+
+		v, err := extractValue(obj)
+		if err != nil {
+			return nil, nil, fmt.Errorf("variable %s: %w", name, err)
+		}
+
+		vars[name] = v
+	}
+
+	return value, vars, nil
+}
+
+// выполняет код, возвращает результат и окружение с итоговыми значениями глобальных переменных.
+// Ошибка выполнения скрипта возвращается как *Error
+func (p *Program) exec(ctx gocontext.Context, botCtx *context.Context, passVars *[]string) (object.Object, *object.Env, error) {
+	env := object.NewEnv()
+	for _, hf := range p.functions {
+		env.Set(hf.Name, hf.Builtin(ctx))
+	}
+	if p.clock != nil {
+		env.Set("now", object.NowBuiltin(p.clock))
+	}
+	if p.seed != 0 {
+		// new source for each execution, Run can be called concurrently
+		for name, builtin := range object.RandomBuiltins(rand.New(rand.NewSource(p.seed))) {
+			env.Set(name, builtin)
+		}
+	}
+
+	env, err := object.ConvertContextToEnv(botCtx, env, passVars, p.decimals)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ev, err := p.eval(ctx, env)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if errObj, ok := ev.(*object.Error); ok {
+		line, column := errObj.Pos.Line, 0
+		if line != 0 {
+			column = errObj.Pos.Offset + 1
+		}
+
+		return nil, nil, newError([]Diagnostic{
+			newDiagnostic(p.code, RuntimeError, line, column, errObj.Message),
+		})
+	}
+
+	return ev, env, nil
+}
+
+func extractValue(obj object.Object) (any, error) {
+	v, ok := object.ExtractRawValueFromObject(obj)
+	if !ok {
+		return nil, fmt.Errorf("%s", v)
+	}
+
+	return v, nil
+}
+
+// возвращает результат выполнения выбранным способом и ошибку превышения ограничения
+func (p *Program) eval(ctx gocontext.Context, env *object.Env) (object.Object, error) {
+	if p.bytecode != nil {
+		m := vm.New(ctx, p.limits)
+		ev := m.Run(p.bytecode, env)
+		return ev, m.Err()
+	}
+
+	e := evaluator.New(ctx, p.limits)
+	ev := e.Eval(p.program, env)
+	return ev, e.Err()
+}
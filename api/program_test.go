@@ -0,0 +1,332 @@
+package api
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/botscubes/bot-components/context"
+)
+
+var backends = []Backend{EvaluatorBackend, VMBackend}
+
+func newBotContext(t *testing.T, data string) *context.Context {
+	t.Helper()
+
+	ctx, err := context.NewContextFromJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("context error: %v", err)
+	}
+
+	return ctx
+}
+
+func TestCompileRun(t *testing.T) {
+	botCtx := newBotContext(t, `{"count": 2, "name": "bot", "price": 1.5, "items": [1, 2, 3], "user": {"age": 30}}`)
+	passVars := []string{"count", "name", "price", "items", "user"}
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"count + 1", int64(3)},
+		{"price * 2", 3.0},
+		{"`hello, ${name}`", "hello, bot"},
+		{"map(items, fn(x) { x * count })", []any{int64(2), int64(4), int64(6)}},
+		{"user.age >= 18", true},
+		{`{"a": count, "b": [name]}`, map[string]any{"a": int64(2), "b": []any{"bot"}}},
+		{"f = fn(n) { if (n < 2) { return n }; f(n - 1) + f(n - 2) }; f(10)", int64(55)},
+		{"null", nil},
+		// nested function uses variables which are assigned later
+		{"f = fn() { inc = fn() { n = n + count }; n = 0; inc(); inc(); n }; f()", int64(4)},
+		{"f = fn() { g = fn() { q = name }; let q = 1; g(); q }; f()", "bot"},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			p, err := Compile(test.input, Config{Limits: DefaultLimits, Backend: backend})
+			if err != nil {
+				t.Fatalf("%s: compile error: %v in test: %q", backend, err, test.input)
+			}
+
+			result, err := p.Run(gocontext.Background(), botCtx, &passVars)
+			if err != nil {
+				t.Errorf("%s: run error: %v in test: %q", backend, err, test.input)
+				continue
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("%s: wrong result. got: %#v expected: %#v in test: %q", backend, result, test.expected, test.input)
+			}
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	botCtx := newBotContext(t, `{"count": 2}`)
+
+	tests := []struct {
+		input    string
+		passVars []string
+		expected string
+	}{
+		{"count / 0", []string{"count"}, "runtime error: 1:1: division by zero"},
+		{"x = 1\ny", nil, "runtime error: 2:1: identifier not found: y"},
+		{"count", []string{"missing"}, "variable does not exists"},
+		{"fn() { 1 }", nil, "unsupported result type: FUNCTION"},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			p, err := Compile(test.input, Config{Backend: backend})
+			if err != nil {
+				t.Fatalf("%s: compile error: %v in test: %q", backend, err, test.input)
+			}
+
+			_, err = p.Run(gocontext.Background(), botCtx, &test.passVars)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("%s: wrong error. got: %v expected: %s in test: %q", backend, err, test.expected, test.input)
+			}
+		}
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	botCtx := newBotContext(t, `{}`)
+
+	for _, backend := range backends {
+		p, err := Compile("while (true) { }", Config{Limits: Limits{MaxSteps: 1000}, Backend: backend})
+		if err != nil {
+			t.Fatalf("%s: compile error: %v", backend, err)
+		}
+
+		_, err = p.Run(gocontext.Background(), botCtx, &[]string{})
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "STEPS" {
+			t.Errorf("%s: expected steps limit error, got: %v", backend, err)
+		}
+	}
+}
+
+// Program is compiled once and run with different contexts, runs don't affect each other
+func TestProgramReuse(t *testing.T) {
+	code := "items = []; for (i in range(count)) { push(items, i) }; total = total + len(items); total"
+	passVars := []string{"count", "total"}
+
+	for _, backend := range backends {
+		p, err := Compile(code, Config{Backend: backend})
+		if err != nil {
+			t.Fatalf("%s: compile error: %v", backend, err)
+		}
+
+		for i := int64(0); i < 5; i++ {
+			botCtx := newBotContext(t, fmt.Sprintf(`{"count": %d, "total": 100}`, i))
+
+			result, err := p.Run(gocontext.Background(), botCtx, &passVars)
+			if err != nil {
+				t.Fatalf("%s: run error: %v", backend, err)
+			}
+
+			if result != 100+i {
+				t.Errorf("%s: wrong result for count %d. got: %v expected: %d", backend, i, result, 100+i)
+			}
+		}
+	}
+}
+
+// Program is run from several goroutines at the same time, run with go test -race
+func TestProgramConcurrentRun(t *testing.T) {
+	code := "m = {}; for (i in range(n)) { m[intToString(i)] = i * n }; s = 0; for (k, v in m) { s += v }; [s, randomInt(1, 6)]"
+
+	for _, backend := range backends {
+		p, err := Compile(code, Config{Backend: backend, RandomSeed: 1})
+		if err != nil {
+			t.Fatalf("%s: compile error: %v", backend, err)
+		}
+
+		var wg sync.WaitGroup
+		results := make([]any, 20)
+		errs := make([]error, len(results))
+		for id := range results {
+			botCtx := newBotContext(t, fmt.Sprintf(`{"n": %d}`, id))
+
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				results[id], errs[id] = p.Run(gocontext.Background(), botCtx, &[]string{"n"})
+			}(id)
+		}
+		wg.Wait()
+
+		var dice any
+		for id, result := range results {
+			if errs[id] != nil {
+				t.Fatalf("%s: run error: %v", backend, errs[id])
+			}
+
+			n := int64(id)
+			values := result.([]any)
+			if values[0] != n*n*(n-1)/2 {
+				t.Errorf("%s: wrong sum for n %d. got: %v expected: %d", backend, n, values[0], n*n*(n-1)/2)
+			}
+
+			// same seed gives same numbers in each run
+			if dice == nil {
+				dice = values[1]
+			}
+			if values[1] != dice {
+				t.Errorf("%s: random numbers differ with fixed seed: %v and %v", backend, dice, values[1])
+			}
+		}
+	}
+}
+
+func TestRunWithVars(t *testing.T) {
+	botCtx := newBotContext(t, `{"count": 1, "cart": ["apple"], "item": "pear", "profile": {"name": "Ann"}}`)
+
+	tests := []struct {
+		input    string
+		passVars []string
+		outVars  []string
+		value    any
+		vars     map[string]any
+	}{
+		{
+			"count = count + 1; push(cart, item)",
+			[]string{"count", "cart", "item"},
+			[]string{"count", "cart"},
+			[]any{"apple", "pear"},
+			map[string]any{"count": int64(2), "cart": []any{"apple", "pear"}},
+		},
+		// variables assigned in code are returned, unknown names are skipped
+		{
+			"total = count * 10; let local = 1",
+			[]string{"count"},
+			[]string{"total", "local", "unknown", "item"},
+			nil,
+			map[string]any{"total": int64(10), "local": int64(1)},
+		},
+		// function changes variable from context, its own variables stay local
+		{
+			"inc = fn(n) { tmp = n; count += tmp }; inc(5); inc(2); count",
+			[]string{"count"},
+			[]string{"count", "tmp"},
+			int64(8),
+			map[string]any{"count": int64(8)},
+		},
+		{
+			"profile.name = upper(profile.name)",
+			[]string{"profile"},
+			[]string{"profile"},
+			nil,
+			map[string]any{"profile": map[string]any{"name": "ANN"}},
+		},
+		{"count", []string{"count"}, nil, int64(1), map[string]any{}},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			p, err := Compile(test.input, Config{Backend: backend})
+			if err != nil {
+				t.Fatalf("%s: compile error: %v in test: %q", backend, err, test.input)
+			}
+
+			value, vars, err := p.RunWithVars(gocontext.Background(), botCtx, &test.passVars, test.outVars)
+			if err != nil {
+				t.Errorf("%s: run error: %v in test: %q", backend, err, test.input)
+				continue
+			}
+
+			if !reflect.DeepEqual(value, test.value) {
+				t.Errorf("%s: wrong value. got: %#v expected: %#v in test: %q", backend, value, test.value, test.input)
+			}
+
+			if !reflect.DeepEqual(vars, test.vars) {
+				t.Errorf("%s: wrong vars. got: %#v expected: %#v in test: %q", backend, vars, test.vars, test.input)
+			}
+		}
+	}
+}
+
+func TestRunWithVarsErrors(t *testing.T) {
+	botCtx := newBotContext(t, `{"count": 1}`)
+
+	tests := []struct {
+		input    string
+		passVars []string
+		outVars  []string
+		expected string
+	}{
+		// variable from passVars is missing in context
+		{"count", []string{"count", "missing"}, []string{"count"}, "variable does not exists"},
+		{"f = fn() { 1 }", nil, []string{"f"}, "variable f: unsupported result type: FUNCTION"},
+		{"count = count / 0", []string{"count"}, []string{"count"}, "runtime error: 1:9: division by zero"},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			p, err := Compile(test.input, Config{Backend: backend})
+			if err != nil {
+				t.Fatalf("%s: compile error: %v in test: %q", backend, err, test.input)
+			}
+
+			_, vars, err := p.RunWithVars(gocontext.Background(), botCtx, &test.passVars, test.outVars)
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("%s: wrong error. got: %v expected: %s in test: %q", backend, err, test.expected, test.input)
+			}
+
+			if vars != nil {
+				t.Errorf("%s: vars returned with error: %v in test: %q", backend, vars, test.input)
+			}
+		}
+	}
+}
+
+func TestRunDecimals(t *testing.T) {
+	botCtx := newBotContext(t, `{"price": 19.99, "qty": 3, "items": [{"p": 0.1}, {"p": 0.2}]}`)
+	passVars := []string{"price", "qty", "items"}
+
+	tests := []struct {
+		input    string
+		decimals bool
+		expected any
+	}{
+		{"price * qty", true, big.NewRat(5997, 100)},
+		{"qty / 2", true, int64(1)},
+		{"items[0].p + items[1].p == 0.3d", true, true},
+		{"price", false, 19.99},
+		{"items[0].p + items[1].p == 0.3", false, false},
+		{"decimal(items[0].p) + decimal(items[1].p) == 0.3d", false, true},
+	}
+
+	for _, backend := range backends {
+		for _, test := range tests {
+			p, err := Compile(test.input, Config{Backend: backend, Decimals: test.decimals})
+			if err != nil {
+				t.Fatalf("%s: compile error: %v in test: %q", backend, err, test.input)
+			}
+
+			result, err := p.Run(gocontext.Background(), botCtx, &passVars)
+			if err != nil {
+				t.Errorf("%s: run error: %v in test: %q", backend, err, test.input)
+				continue
+			}
+
+			if r, ok := result.(*big.Rat); ok {
+				if r.Cmp(test.expected.(*big.Rat)) != 0 {
+					t.Errorf("%s: wrong result. got: %s expected: %s in test: %q", backend, r, test.expected, test.input)
+				}
+				continue
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("%s: wrong result. got: %#v expected: %#v in test: %q", backend, result, test.expected, test.input)
+			}
+		}
+	}
+}
@@ -6,12 +6,19 @@ import (
 	"runtime"
 
 	"github.com/botscubes/bql/internal/app"
+	"github.com/botscubes/bql/internal/repl"
 	"github.com/botscubes/bql/pkg/logger"
 )
 
 // можно запустить как самостоятельную программу. (go run ./cmd/main.go input.txt)
+// интерактивный режим: go run ./cmd/main.go repl
 // для использовать в качестве модуля см. ../api/api.go
 func main() {
+	if len(os.Args) == 2 && os.Args[1] == "repl" {
+		repl.New(os.Stdin, os.Stdout).Start()
+		return
+	}
+
 	log, err := logger.NewLogger(logger.Config{
 		Type: "dev",
 	})
@@ -29,7 +36,7 @@ func main() {
 	}()
 
 	if len(os.Args) != 2 {
-		log.Info(`example usage: ./main code.txt`)
+		log.Info(`example usage: ./main code.txt or ./main repl`)
 		return
 	}
 
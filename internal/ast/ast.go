@@ -13,8 +13,19 @@ var nl = "\n"
 type Node interface {
 	TokenLiteral() string
 	ToString() string
+	Pos() token.Pos // position of first char of node
+	End() token.Pos // position of char after node
 }
 
+// position of node in source code, embedded in all nodes (except Program)
+type Span struct {
+	StartPos token.Pos
+	EndPos   token.Pos
+}
+
+func (s Span) Pos() token.Pos { return s.StartPos }
+func (s Span) End() token.Pos { return s.EndPos }
+
 // All statement nodes implement
 type Statement interface {
 	Node
@@ -39,6 +50,20 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Pos {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Pos{Line: 1}
+}
+
+func (p *Program) End() token.Pos {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Pos{Line: 1}
+}
+
 func (p *Program) ToString() string {
 	// TODO: replace to:
 	// r := strings.NewReader("foobar")
@@ -66,8 +91,10 @@ func (p *Program) Tree() string {
 
 // Statements
 type AssignStatement struct {
-	Name  *Ident
-	Value Expression
+	Span
+	Name     *Ident
+	Operator string // =, +=, -=, *=, /=, %=
+	Value    Expression
 }
 
 func (as *AssignStatement) statementNode()       {}
@@ -76,7 +103,7 @@ func (as *AssignStatement) ToString() string {
 	var out bytes.Buffer
 
 	out.WriteString(as.Name.TokenLiteral())
-	out.WriteString(" = ")
+	out.WriteString(" " + assignOperator(as.Operator) + " ")
 
 	if as.Value != nil {
 		out.WriteString(as.Value.ToString())
@@ -87,7 +114,70 @@ func (as *AssignStatement) ToString() string {
 	return out.String()
 }
 
+// declaration of variable in current scope: let x = value, const x = value
+type LetStatement struct {
+	Span
+	Token token.Token // let or const
+	Name  *Ident
+	Value Expression
+}
+
+func (ls *LetStatement) statementNode()       {}
+func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.Value)
+	out.WriteString(" = ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.ToString())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// variable declared with const can not be assigned
+func (ls *LetStatement) IsConst() bool { return ls.Token.Type == token.CONST }
+
+// assignment to element of array or hash map: a[i] = value, m.key += value
+type IndexAssignStatement struct {
+	Span
+	Target   Expression // IndexExpression or MemberExpression
+	Operator string     // =, +=, -=, *=, /=, %=
+	Value    Expression
+}
+
+func (ia *IndexAssignStatement) statementNode()       {}
+func (ia *IndexAssignStatement) TokenLiteral() string { return "" }
+func (ia *IndexAssignStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString(ia.Target.ToString())
+	out.WriteString(" " + assignOperator(ia.Operator) + " ")
+
+	if ia.Value != nil {
+		out.WriteString(ia.Value.ToString())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+func assignOperator(op string) string {
+	if op == "" {
+		return "="
+	}
+
+	return op
+}
+
 type ExpressionStatement struct {
+	Span
 	Token      token.Token
 	Expression Expression
 }
@@ -102,6 +192,7 @@ func (es *ExpressionStatement) ToString() string {
 }
 
 type BlockStatement struct {
+	Span
 	Token      token.Token // {
 	Statements []Statement
 }
@@ -118,7 +209,19 @@ func (bs *BlockStatement) ToString() string {
 	return out.String()
 }
 
+// block with let or const statements has own scope
+func (bs *BlockStatement) HasDeclarations() bool {
+	for _, s := range bs.Statements {
+		if _, ok := s.(*LetStatement); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 type ReturnStatement struct {
+	Span
 	Token token.Token // return
 	Value Expression
 }
@@ -139,8 +242,82 @@ func (rs *ReturnStatement) ToString() string {
 	return out.String()
 }
 
+type WhileStatement struct {
+	Span
+	Token     token.Token // while
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("while ( ")
+	out.WriteString(ws.Condition.ToString())
+	out.WriteString(" ) { ")
+	out.WriteString(ws.Body.ToString())
+	out.WriteString(" } ")
+
+	return out.String()
+}
+
+// for (value in iterable) { }
+// for (key, value in iterable) { }
+//
+// for an array key is the index of element,
+// for a hash map with one variable value is the key of pair
+type ForStatement struct {
+	Span
+	Token    token.Token // for
+	Key      *Ident      // nil if only one variable
+	Value    *Ident
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("for ( ")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.ToString())
+		out.WriteString(", ")
+	}
+	out.WriteString(fs.Value.ToString())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.ToString())
+	out.WriteString(" ) { ")
+	out.WriteString(fs.Body.ToString())
+	out.WriteString(" } ")
+
+	return out.String()
+}
+
+type BreakStatement struct {
+	Span
+	Token token.Token // break
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) ToString() string     { return bs.Token.Literal + ";" }
+
+type ContinueStatement struct {
+	Span
+	Token token.Token // continue
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) ToString() string     { return cs.Token.Literal + ";" }
+
 // Expressions
 type IntegerLiteral struct {
+	Span
 	Token token.Token // 5 6
 	Value int64
 }
@@ -149,7 +326,28 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) ToString() string     { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Span
+	Token token.Token // 3.14 1e-3
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) ToString() string     { return fl.Token.Literal }
+
+type DecimalLiteral struct {
+	Span
+	Token token.Token // 19.99d
+	Value string      // literal without suffix
+}
+
+func (dl *DecimalLiteral) expressionNode()      {}
+func (dl *DecimalLiteral) TokenLiteral() string { return dl.Token.Literal }
+func (dl *DecimalLiteral) ToString() string     { return dl.Token.Literal }
+
 type Boolean struct {
+	Span
 	Token token.Token // TRUE, FALSE
 	Value bool
 }
@@ -158,7 +356,17 @@ func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) ToString() string     { return b.Token.Literal }
 
+type Null struct {
+	Span
+	Token token.Token // null
+}
+
+func (n *Null) expressionNode()      {}
+func (n *Null) TokenLiteral() string { return n.Token.Literal }
+func (n *Null) ToString() string     { return n.Token.Literal }
+
 type Ident struct {
+	Span
 	Token token.Token // IDENT
 	Value string
 }
@@ -168,6 +376,7 @@ func (i *Ident) TokenLiteral() string { return i.Token.Literal }
 func (i *Ident) ToString() string     { return i.Value }
 
 type InfixExpression struct {
+	Span
 	Token    token.Token // +, -, etc
 	Left     Expression
 	Operator string
@@ -189,6 +398,7 @@ func (oe *InfixExpression) ToString() string {
 }
 
 type PrefixExpression struct {
+	Span
 	Token    token.Token // !
 	Operator string
 	Right    Expression
@@ -208,6 +418,7 @@ func (pe *PrefixExpression) ToString() string {
 }
 
 type IfExpression struct {
+	Span
 	Token       token.Token // if
 	Condition   Expression
 	Consequence *BlockStatement
@@ -236,6 +447,7 @@ func (ie *IfExpression) ToString() string {
 }
 
 type FunctionLiteral struct {
+	Span
 	Token      token.Token // 'fn'
 	Parameters []*Ident
 	Body       *BlockStatement
@@ -263,6 +475,7 @@ func (fl *FunctionLiteral) ToString() string {
 }
 
 type CallExpression struct {
+	Span
 	Token     token.Token // '('
 	Function  Expression  // Ident
 	Arguments []Expression
@@ -287,6 +500,7 @@ func (ce *CallExpression) ToString() string {
 }
 
 type StringLiteral struct {
+	Span
 	Token token.Token
 	Value string
 }
@@ -295,7 +509,36 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) ToString() string     { return sl.Token.Literal }
 
+// template string: `Hello, ${name}!`
+type TemplateLiteral struct {
+	Span
+	Token token.Token  // first part of template
+	Parts []Expression // StringLiteral for text, other expressions are converted to string
+}
+
+func (tl *TemplateLiteral) expressionNode()      {}
+func (tl *TemplateLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TemplateLiteral) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("`")
+	for _, part := range tl.Parts {
+		if s, ok := part.(*StringLiteral); ok {
+			out.WriteString(s.Value)
+			continue
+		}
+
+		out.WriteString("${")
+		out.WriteString(part.ToString())
+		out.WriteString("}")
+	}
+	out.WriteString("`")
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
+	Span
 	Token    token.Token // '['
 	Elements []Expression
 }
@@ -318,9 +561,11 @@ func (al *ArrayLiteral) ToString() string {
 }
 
 type IndexExpression struct {
-	Token token.Token // [
-	Left  Expression
-	Index Expression
+	Span
+	Token    token.Token // [ or ?[
+	Left     Expression
+	Index    Expression
+	Optional bool // result is null if Left is null (?[)
 }
 
 func (ie *IndexExpression) expressionNode()      {}
@@ -330,16 +575,71 @@ func (ie *IndexExpression) ToString() string {
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.ToString())
-	out.WriteString("[")
+	out.WriteString(ie.Token.Literal)
 	out.WriteString(ie.Index.ToString())
 	out.WriteString("])")
 
 	return out.String()
 }
 
+// part of array or string: a[low:high], low and high can be omitted
+type SliceExpression struct {
+	Span
+	Token    token.Token // [ or ?[
+	Left     Expression
+	Low      Expression // nil if omitted
+	High     Expression // nil if omitted
+	Optional bool       // result is null if Left is null (?[)
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.ToString())
+	out.WriteString(se.Token.Literal)
+	if se.Low != nil {
+		out.WriteString(se.Low.ToString())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.ToString())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// access to value of hash map by string key: m.key, m?.key
+type MemberExpression struct {
+	Span
+	Token    token.Token // . or ?.
+	Left     Expression
+	Property *Ident
+	Optional bool // result is null if Left is null (?.)
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) ToString() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Left.ToString())
+	out.WriteString(me.Token.Literal)
+	out.WriteString(me.Property.Value)
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type HashMapLiteral struct {
+	Span
 	Token token.Token // {
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
 }
 
 func (hl *HashMapLiteral) expressionNode()      {}
@@ -348,8 +648,8 @@ func (hl *HashMapLiteral) ToString() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.ToString()+":"+value.ToString())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.ToString()+":"+hl.Pairs[key].ToString())
 	}
 
 	out.WriteString("{")
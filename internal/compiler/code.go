@@ -0,0 +1,230 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpNull
+	OpNil // pushes Go nil, value of statement (assign, loop)
+	OpTrue
+	OpFalse
+	OpPop
+	OpDup // operand - number of values on top of stack to duplicate
+
+	OpPrefix
+	OpInfix
+	OpIndex
+	OpSlice     // bounds are in stack, null if omitted
+	OpMember    // operand - constant with name
+	OpSetMember // operand - constant with name
+	OpSetIndex
+	OpArray
+	OpHash
+	OpTemplate // operand - number of parts in stack
+
+	OpJump
+	OpJumpIfFalse
+	OpJumpIfNull // jumps if value on top of stack is null, value stays in stack
+	OpCoalesce   // jumps if value on top of stack is not null, otherwise removes value
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetOuter
+	OpSetOuter
+	OpGetDynamic // operands - depth, index of local variable, index of global variable (see Symbol.Dynamic)
+	OpSetDynamic
+	// let and const, operands - index of variable, 1 for constant.
+	// Set ops return error for variable which is constant at runtime
+	OpDeclareGlobal
+	OpDeclareLocal
+	OpEnterScope // operand - constant with BlockScope
+	OpLeaveScope
+
+	OpClosure
+	OpCall
+	OpReturnValue
+
+	OpIterInit
+	OpIterNext
+)
+
+// kinds of condition for OpJumpIfFalse, used in error message
+const (
+	CondIf byte = iota
+	CondWhile
+)
+
+// modes of OpIterNext
+const (
+	IterValue    byte = iota // pushes element of array or key of hash map
+	IterKeyValue             // pushes index and element of array or key and value of hash map
+)
+
+// operators of OpPrefix and OpInfix, operand is index in this list
+var Operators = []string{"+", "-", "*", "/", "%", "==", "!=", "<", ">", "<=", ">=", "&&", "||", "!"}
+
+func operatorIndex(op string) (int, bool) {
+	for id, o := range Operators {
+		if o == op {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
+
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpNull:     {"OpNull", []int{}},
+	OpNil:      {"OpNil", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{1}},
+
+	OpPrefix:    {"OpPrefix", []int{1}},
+	OpInfix:     {"OpInfix", []int{1}},
+	OpIndex:     {"OpIndex", []int{}},
+	OpSlice:     {"OpSlice", []int{}},
+	OpMember:    {"OpMember", []int{2}},
+	OpSetMember: {"OpSetMember", []int{2}},
+	OpSetIndex:  {"OpSetIndex", []int{}},
+	OpArray:     {"OpArray", []int{2}},
+	OpHash:      {"OpHash", []int{2}},
+	OpTemplate:  {"OpTemplate", []int{2}},
+
+	OpJump:        {"OpJump", []int{2}},
+	OpJumpIfFalse: {"OpJumpIfFalse", []int{2, 1}},
+	OpJumpIfNull:  {"OpJumpIfNull", []int{2}},
+	OpCoalesce:    {"OpCoalesce", []int{2}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{2}},
+	OpSetLocal:  {"OpSetLocal", []int{2}},
+	OpGetOuter:  {"OpGetOuter", []int{1, 2}},
+	OpSetOuter:  {"OpSetOuter", []int{1, 2}},
+
+	OpGetDynamic: {"OpGetDynamic", []int{1, 2, 2}},
+	OpSetDynamic: {"OpSetDynamic", []int{1, 2, 2}},
+
+	OpDeclareGlobal: {"OpDeclareGlobal", []int{2, 1}},
+	OpDeclareLocal:  {"OpDeclareLocal", []int{2, 1}},
+
+	OpEnterScope: {"OpEnterScope", []int{2}},
+	OpLeaveScope: {"OpLeaveScope", []int{}},
+
+	OpClosure:     {"OpClosure", []int{2}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+
+	OpIterInit: {"OpIterInit", []int{}},
+	OpIterNext: {"OpIterNext", []int{2, 1}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// maximum value of operand with given width
+func maxOperand(width int) int {
+	return 1<<(8*width) - 1
+}
+
+// returns encoded instruction, operands must fit into their widths
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for id, o := range operands {
+		width := def.OperandWidths[id]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// returns decoded operands and number of read bytes
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for id, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[id] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[id] = int(ins[offset])
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// disassembled instructions, one per line
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s", i, def.Name)
+		for _, o := range operands {
+			fmt.Fprintf(&out, " %d", o)
+		}
+		out.WriteString("\n")
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
@@ -0,0 +1,913 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/botscubes/bql/internal/ast"
+	"github.com/botscubes/bql/internal/object"
+	"github.com/botscubes/bql/internal/token"
+)
+
+type Error struct {
+	Pos     token.Pos
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("pos: %d:%d: %s", e.Pos.Line, e.Pos.Offset, e.Message)
+}
+
+type Bytecode struct {
+	Main        *object.CompiledFunction // top level code
+	Constants   []object.Object
+	GlobalNames []string // names of global variables by index
+}
+
+type loop struct {
+	start   int          // target of continue
+	breaks  []int        // positions of jumps to end of loop
+	symbols *SymbolTable // table outside of loop, scopes of blocks inside loop are left by break and continue
+}
+
+// instructions of function being compiled
+type compilationScope struct {
+	instructions Instructions
+	positions    []object.Position
+	loops        []*loop
+}
+
+type Compiler struct {
+	constants []object.Object
+	symbols   *SymbolTable
+	scopes    []*compilationScope
+
+	pos token.Pos // position of node being compiled
+}
+
+func New() *Compiler {
+	return &Compiler{
+		symbols: NewSymbolTable(),
+		scopes:  []*compilationScope{{}},
+	}
+}
+
+// compiles program, returns Error if program exceeds limits of bytecode
+func Compile(program *ast.Program) (*Bytecode, error) {
+	c := New()
+	if err := c.compileProgram(program); err != nil {
+		return nil, err
+	}
+
+	return c.Bytecode(), nil
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	scope := c.scope()
+	return &Bytecode{
+		Main: &object.CompiledFunction{
+			Instructions: scope.instructions,
+			Positions:    scope.positions,
+			Source:       "main",
+		},
+		Constants:   c.constants,
+		GlobalNames: c.symbols.Names(),
+	}
+}
+
+func (c *Compiler) newError(formating string, parameters ...any) error {
+	return Error{Pos: c.pos, Message: fmt.Sprintf(formating, parameters...)}
+}
+
+// sets position of node for emitted instructions, returned function restores previous position
+func (c *Compiler) at(n ast.Node) func() {
+	prev := c.pos
+	c.pos = n.Pos()
+	return func() { c.pos = prev }
+}
+
+// value of program is value of last statement, statements without value leave nil
+func (c *Compiler) compileProgram(program *ast.Program) error {
+	c.hoist(program.Statements, true)
+
+	for _, stmt := range program.Statements {
+		if s, ok := stmt.(*ast.ExpressionStatement); ok {
+			if err := c.compileExpression(s.Expression); err != nil {
+				return err
+			}
+		} else {
+			if err := c.compileStatement(stmt); err != nil {
+				return err
+			}
+			c.emit(OpNil)
+		}
+
+		c.emit(OpPop)
+	}
+
+	return nil
+}
+
+// defines variables of program, function or block before compilation of its statements (see SymbolTable.Hoist),
+// so function uses variable which is assigned later in code.
+// Declarations (let, const) belong to the scope of statements, assignments of nested blocks - to the scope
+// of function (assigned is true for program and function)
+func (c *Compiler) hoist(statements []ast.Statement, assigned bool) {
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			c.symbols.Hoist(stmt.Name.Value)
+		case *ast.AssignStatement:
+			if assigned {
+				c.symbols.Hoist(stmt.Name.Value)
+			}
+		}
+
+		if assigned {
+			for _, block := range nestedBlocks(stmt) {
+				c.hoistAssigned(block.Statements)
+			}
+		}
+	}
+}
+
+// hoists assignments of nested blocks, their declarations are hoisted when block is compiled
+func (c *Compiler) hoistAssigned(statements []ast.Statement) {
+	for _, stmt := range statements {
+		if s, ok := stmt.(*ast.AssignStatement); ok {
+			c.symbols.Hoist(s.Name.Value)
+		}
+
+		for _, block := range nestedBlocks(stmt) {
+			c.hoistAssigned(block.Statements)
+		}
+	}
+}
+
+// blocks of loops and if expressions of statement, blocks of functions are not included
+func nestedBlocks(stmt ast.Statement) []*ast.BlockStatement {
+	var exp ast.Expression
+	switch stmt := stmt.(type) {
+	case *ast.WhileStatement:
+		return []*ast.BlockStatement{stmt.Body}
+	case *ast.ForStatement:
+		return []*ast.BlockStatement{stmt.Body}
+	case *ast.ExpressionStatement:
+		exp = stmt.Expression
+	case *ast.AssignStatement:
+		exp = stmt.Value
+	case *ast.LetStatement:
+		exp = stmt.Value
+	case *ast.ReturnStatement:
+		exp = stmt.Value
+	}
+
+	ifExp, ok := exp.(*ast.IfExpression)
+	if !ok {
+		return nil
+	}
+
+	blocks := []*ast.BlockStatement{ifExp.Consequence}
+	if ifExp.Alternative != nil {
+		blocks = append(blocks, ifExp.Alternative)
+	}
+
+	return blocks
+}
+
+// compiled statement does not change stack
+func (c *Compiler) compileStatement(stmt ast.Statement) error {
+	defer c.at(stmt)()
+
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		if err := c.compileExpression(stmt.Expression); err != nil {
+			return err
+		}
+		c.emit(OpPop)
+
+	case *ast.BlockStatement:
+		for _, s := range stmt.Statements {
+			if err := c.compileStatement(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.AssignStatement:
+		if stmt.Operator != "" && stmt.Operator != "=" {
+			return c.compileCompoundAssign(stmt)
+		}
+
+		return c.compileAssign(stmt.Name.Value, stmt.Value)
+
+	case *ast.LetStatement:
+		return c.compileLet(stmt)
+
+	case *ast.IndexAssignStatement:
+		return c.compileIndexAssign(stmt)
+
+	case *ast.ReturnStatement:
+		if err := c.compileExpression(stmt.Value); err != nil {
+			return err
+		}
+		c.emit(OpReturnValue)
+
+	case *ast.WhileStatement:
+		return c.compileWhile(stmt)
+
+	case *ast.ForStatement:
+		return c.compileFor(stmt)
+
+	case *ast.BreakStatement:
+		l := c.loop()
+		if l == nil {
+			return c.newError("break outside loop")
+		}
+		c.leaveBlocks(l.symbols)
+		l.breaks = append(l.breaks, c.emit(OpJump, 0))
+
+	case *ast.ContinueStatement:
+		l := c.loop()
+		if l == nil {
+			return c.newError("continue outside loop")
+		}
+		c.leaveBlocks(l.symbols)
+		c.emit(OpJump, l.start)
+
+	default:
+		return c.newError("unsupported statement: %T", stmt)
+	}
+
+	return nil
+}
+
+// compiled block pushes value of last expression statement or NULL
+// block of if expression, it has own scope if it has declarations
+func (c *Compiler) compileBranch(block *ast.BlockStatement) error {
+	if block == nil || !block.HasDeclarations() {
+		return c.compileBlockValue(block)
+	}
+
+	id, err := c.enterBlock()
+	if err != nil {
+		return err
+	}
+	c.hoist(block.Statements, false)
+
+	if err := c.compileBlockValue(block); err != nil {
+		return err
+	}
+	c.leaveBlock(id)
+
+	return nil
+}
+
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if block == nil || len(block.Statements) == 0 {
+		c.emit(OpNull)
+		return nil
+	}
+
+	last := len(block.Statements) - 1
+	for _, s := range block.Statements[:last] {
+		if err := c.compileStatement(s); err != nil {
+			return err
+		}
+	}
+
+	if s, ok := block.Statements[last].(*ast.ExpressionStatement); ok {
+		return c.compileExpression(s.Expression)
+	}
+
+	if err := c.compileStatement(block.Statements[last]); err != nil {
+		return err
+	}
+	c.emit(OpNull)
+
+	return nil
+}
+
+// assignment changes the nearest variable with the name (see SymbolTable.ResolveAssign)
+func (c *Compiler) compileAssign(name string, value ast.Expression) error {
+	// function can call itself by name
+	if _, ok := value.(*ast.FunctionLiteral); ok {
+		sym, depth := c.symbols.ResolveAssign(name)
+		if err := c.compileExpression(value); err != nil {
+			return err
+		}
+
+		return c.setSymbol(sym, depth)
+	}
+
+	if err := c.compileExpression(value); err != nil {
+		return err
+	}
+
+	return c.assignSymbol(name)
+}
+
+func (c *Compiler) assignSymbol(name string) error {
+	sym, depth := c.symbols.ResolveAssign(name)
+	return c.setSymbol(sym, depth)
+}
+
+// let and const define variable in current scope, assignment to constant is checked at runtime
+func (c *Compiler) compileLet(stmt *ast.LetStatement) error {
+	// function can call itself by name
+	_, isFunc := stmt.Value.(*ast.FunctionLiteral)
+	if isFunc {
+		c.symbols.Define(stmt.Name.Value)
+	}
+
+	if err := c.compileExpression(stmt.Value); err != nil {
+		return err
+	}
+
+	// dynamic variable of scope (see SymbolTable.Hoist) is set without fallback to global
+	sym := c.symbols.Define(stmt.Name.Value)
+	if sym.Index > maxOperand(2) {
+		return c.newError("too many variables")
+	}
+
+	constant := 0
+	if stmt.IsConst() {
+		constant = 1
+	}
+
+	if sym.Scope == GlobalScope {
+		c.emit(OpDeclareGlobal, sym.Index, constant)
+	} else {
+		c.emit(OpDeclareLocal, sym.Index, constant)
+	}
+
+	return nil
+}
+
+func (c *Compiler) setSymbol(sym Symbol, depth int) error {
+	if sym.Index > maxOperand(2) || sym.Global > maxOperand(2) {
+		return c.newError("too many variables")
+	}
+
+	switch {
+	case sym.Dynamic && depth <= maxOperand(1):
+		c.emit(OpSetDynamic, depth, sym.Index, sym.Global)
+	case sym.Scope == GlobalScope:
+		c.emit(OpSetGlobal, sym.Index)
+	case depth == 0:
+		c.emit(OpSetLocal, sym.Index)
+	case depth <= maxOperand(1):
+		c.emit(OpSetOuter, depth, sym.Index)
+	default:
+		return c.newError("too deep nesting of functions")
+	}
+
+	return nil
+}
+
+// starts scope of block, returns id of constant with names of variables of block
+func (c *Compiler) enterBlock() (int, error) {
+	id, err := c.addConstant(&object.BlockScope{})
+	if err != nil {
+		return 0, err
+	}
+
+	c.emit(OpEnterScope, id)
+	c.symbols = NewBlockSymbolTable(c.symbols)
+
+	return id, nil
+}
+
+func (c *Compiler) leaveBlock(id int) {
+	c.constants[id].(*object.BlockScope).Names = c.symbols.Names()
+	c.symbols = c.symbols.Outer
+	c.emit(OpLeaveScope)
+}
+
+// leaves scopes of blocks until table outer, used by jumps out of blocks
+func (c *Compiler) leaveBlocks(outer *SymbolTable) {
+	for s := c.symbols; s != outer; s = s.Outer {
+		c.emit(OpLeaveScope)
+	}
+}
+
+func (c *Compiler) compileWhile(stmt *ast.WhileStatement) error {
+	start := len(c.scope().instructions)
+	if err := c.compileExpression(stmt.Condition); err != nil {
+		return err
+	}
+	exit := c.emit(OpJumpIfFalse, 0, int(CondWhile))
+
+	return c.compileLoopBody(stmt.Body, start, exit)
+}
+
+func (c *Compiler) compileFor(stmt *ast.ForStatement) error {
+	if err := c.compileExpression(stmt.Iterable); err != nil {
+		return err
+	}
+	c.emit(OpIterInit)
+
+	mode := IterValue
+	if stmt.Key != nil {
+		mode = IterKeyValue
+	}
+
+	start := c.emit(OpIterNext, 0, int(mode))
+
+	// value is on top of stack
+	vars := []*ast.Ident{stmt.Value}
+	if stmt.Key != nil {
+		vars = append(vars, stmt.Key)
+	}
+
+	if err := c.compileLoopBody(stmt.Body, start, start, vars...); err != nil {
+		return err
+	}
+
+	// iterator
+	c.emit(OpPop)
+
+	return nil
+}
+
+// compiles body with jump to start, exit - jump instruction to end of loop
+// body has own scope if it has declarations or variables of loop (vars are taken from stack)
+func (c *Compiler) compileLoopBody(body *ast.BlockStatement, start int, exit int, vars ...*ast.Ident) error {
+	scope := c.scope()
+	l := &loop{start: start, breaks: []int{exit}, symbols: c.symbols}
+	scope.loops = append(scope.loops, l)
+
+	block := len(vars) != 0 || body.HasDeclarations()
+
+	var id int
+	if block {
+		var err error
+		if id, err = c.enterBlock(); err != nil {
+			return err
+		}
+
+		for _, v := range vars {
+			if err := c.setSymbol(c.symbols.Define(v.Value), 0); err != nil {
+				return err
+			}
+		}
+		c.hoist(body.Statements, false)
+	}
+
+	if err := c.compileStatement(body); err != nil {
+		return err
+	}
+
+	if block {
+		c.leaveBlock(id)
+	}
+	c.emit(OpJump, start)
+
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	end := len(scope.instructions)
+	for _, pos := range l.breaks {
+		if err := c.patchJump(pos, end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compiled expression pushes one value
+func (c *Compiler) compileExpression(exp ast.Expression) error {
+	defer c.at(exp)()
+
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return c.emitConstant(&object.Integer{Value: exp.Value})
+
+	case *ast.FloatLiteral:
+		return c.emitConstant(&object.Float{Value: exp.Value})
+
+	case *ast.DecimalLiteral:
+		d, err := object.ParseDecimal(exp.Value)
+		if err != nil {
+			return c.newError("%s", err.Error())
+		}
+
+		return c.emitConstant(d)
+
+	case *ast.StringLiteral:
+		return c.emitConstant(&object.String{Value: exp.Value})
+
+	case *ast.Null:
+		c.emit(OpNull)
+
+	case *ast.Boolean:
+		if exp.Value {
+			c.emit(OpTrue)
+		} else {
+			c.emit(OpFalse)
+		}
+
+	case *ast.Ident:
+		return c.compileIdent(exp.Value)
+
+	case *ast.PrefixExpression:
+		if err := c.compileExpression(exp.Right); err != nil {
+			return err
+		}
+
+		return c.compileOperator(OpPrefix, exp.Operator)
+
+	case *ast.InfixExpression:
+		if err := c.compileExpression(exp.Left); err != nil {
+			return err
+		}
+
+		if exp.Operator == "??" {
+			return c.compileJumpOver(OpCoalesce, exp.Right)
+		}
+
+		if err := c.compileExpression(exp.Right); err != nil {
+			return err
+		}
+
+		return c.compileOperator(OpInfix, exp.Operator)
+
+	case *ast.IfExpression:
+		return c.compileIf(exp)
+
+	case *ast.FunctionLiteral:
+		return c.compileFunction(exp)
+
+	case *ast.CallExpression:
+		if err := c.compileExpression(exp.Function); err != nil {
+			return err
+		}
+
+		if len(exp.Arguments) > maxOperand(1) {
+			return c.newError("too many arguments")
+		}
+
+		for _, arg := range exp.Arguments {
+			if err := c.compileExpression(arg); err != nil {
+				return err
+			}
+		}
+		c.emit(OpCall, len(exp.Arguments))
+
+	case *ast.ArrayLiteral:
+		if len(exp.Elements) > maxOperand(2) {
+			return c.newError("too many elements")
+		}
+
+		for _, el := range exp.Elements {
+			if err := c.compileExpression(el); err != nil {
+				return err
+			}
+		}
+		c.emit(OpArray, len(exp.Elements))
+
+	case *ast.HashMapLiteral:
+		if len(exp.Pairs) > maxOperand(2) {
+			return c.newError("too many elements")
+		}
+
+		for _, k := range exp.Keys {
+			if err := c.compileExpression(k); err != nil {
+				return err
+			}
+			if err := c.compileExpression(exp.Pairs[k]); err != nil {
+				return err
+			}
+		}
+		c.emit(OpHash, len(exp.Pairs))
+
+	case *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		jumps, err := c.compileLink(exp)
+		if err != nil {
+			return err
+		}
+
+		for _, jump := range jumps {
+			if err := c.patchJump(jump, len(c.scope().instructions)); err != nil {
+				return err
+			}
+		}
+
+	case *ast.TemplateLiteral:
+		if len(exp.Parts) > maxOperand(2) {
+			return c.newError("too many parts of template")
+		}
+
+		for _, part := range exp.Parts {
+			if err := c.compileExpression(part); err != nil {
+				return err
+			}
+		}
+		c.emit(OpTemplate, len(exp.Parts))
+
+	default:
+		return c.newError("unsupported expression: %T", exp)
+	}
+
+	return nil
+}
+
+// compiles member, index or slice expression, returns jumps of optional links to the end of chain,
+// so the rest of chain is skipped: x?.a.b and x?.a[0] are null if x is null
+func (c *Compiler) compileLink(exp ast.Expression) ([]int, error) {
+	defer c.at(exp)()
+
+	var left ast.Expression
+	var optional bool
+	switch exp := exp.(type) {
+	case *ast.MemberExpression:
+		left, optional = exp.Left, exp.Optional
+	case *ast.IndexExpression:
+		left, optional = exp.Left, exp.Optional
+	case *ast.SliceExpression:
+		left, optional = exp.Left, exp.Optional
+	}
+
+	var jumps []int
+	var err error
+	switch left.(type) {
+	case *ast.MemberExpression, *ast.IndexExpression, *ast.SliceExpression:
+		jumps, err = c.compileLink(left)
+	default:
+		err = c.compileExpression(left)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if optional {
+		jumps = append(jumps, c.emit(OpJumpIfNull, 0))
+	}
+
+	switch exp := exp.(type) {
+	case *ast.MemberExpression:
+		name, err := c.addConstant(&object.String{Value: exp.Property.Value})
+		if err != nil {
+			return nil, err
+		}
+		c.emit(OpMember, name)
+
+	case *ast.IndexExpression:
+		if err := c.compileExpression(exp.Index); err != nil {
+			return nil, err
+		}
+		c.emit(OpIndex)
+
+	case *ast.SliceExpression:
+		for _, bound := range []ast.Expression{exp.Low, exp.High} {
+			if bound == nil {
+				c.emit(OpNull)
+				continue
+			}
+
+			if err := c.compileExpression(bound); err != nil {
+				return nil, err
+			}
+		}
+		c.emit(OpSlice)
+	}
+
+	return jumps, nil
+}
+
+// emits conditional jump op over compiled exp
+func (c *Compiler) compileJumpOver(op Opcode, exp ast.Expression) error {
+	jump := c.emit(op, 0)
+	if err := c.compileExpression(exp); err != nil {
+		return err
+	}
+
+	return c.patchJump(jump, len(c.scope().instructions))
+}
+
+// x += value: current value, value, operator, assignment
+func (c *Compiler) compileCompoundAssign(stmt *ast.AssignStatement) error {
+	if err := c.compileIdent(stmt.Name.Value); err != nil {
+		return err
+	}
+	if err := c.compileExpression(stmt.Value); err != nil {
+		return err
+	}
+	if err := c.compileOperator(OpInfix, strings.TrimSuffix(stmt.Operator, "=")); err != nil {
+		return err
+	}
+
+	return c.assignSymbol(stmt.Name.Value)
+}
+
+// container and index (or name of member) stay in stack for OpSetIndex (OpSetMember),
+// compound assignment duplicates them to get current value
+func (c *Compiler) compileIndexAssign(stmt *ast.IndexAssignStatement) error {
+	compound := stmt.Operator != "" && stmt.Operator != "="
+
+	switch target := stmt.Target.(type) {
+	case *ast.MemberExpression:
+		if err := c.compileExpression(target.Left); err != nil {
+			return err
+		}
+
+		name, err := c.addConstant(&object.String{Value: target.Property.Value})
+		if err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(OpDup, 1)
+			c.emit(OpMember, name)
+		}
+
+		if err := c.compileAssignedValue(stmt); err != nil {
+			return err
+		}
+		c.emit(OpSetMember, name)
+
+	case *ast.IndexExpression:
+		if err := c.compileExpression(target.Left); err != nil {
+			return err
+		}
+		if err := c.compileExpression(target.Index); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(OpDup, 2)
+			c.emit(OpIndex)
+		}
+
+		if err := c.compileAssignedValue(stmt); err != nil {
+			return err
+		}
+		c.emit(OpSetIndex)
+
+	default:
+		return c.newError("invalid assignment target")
+	}
+
+	return nil
+}
+
+// value of assignment, for compound assignment current value must be in stack
+func (c *Compiler) compileAssignedValue(stmt *ast.IndexAssignStatement) error {
+	if err := c.compileExpression(stmt.Value); err != nil {
+		return err
+	}
+
+	if stmt.Operator == "" || stmt.Operator == "=" {
+		return nil
+	}
+
+	return c.compileOperator(OpInfix, strings.TrimSuffix(stmt.Operator, "="))
+}
+
+// emits OpPrefix or OpInfix with operator
+func (c *Compiler) compileOperator(opcode Opcode, operator string) error {
+	op, ok := operatorIndex(operator)
+	if !ok {
+		return c.newError("unknown operator: %s", operator)
+	}
+	c.emit(opcode, op)
+
+	return nil
+}
+
+func (c *Compiler) compileIdent(name string) error {
+	sym, depth := c.symbols.Resolve(name)
+	if sym.Index > maxOperand(2) || sym.Global > maxOperand(2) {
+		return c.newError("too many variables")
+	}
+
+	switch {
+	case sym.Dynamic && depth <= maxOperand(1):
+		c.emit(OpGetDynamic, depth, sym.Index, sym.Global)
+	case sym.Scope == GlobalScope:
+		c.emit(OpGetGlobal, sym.Index)
+	case depth == 0:
+		c.emit(OpGetLocal, sym.Index)
+	case depth <= maxOperand(1):
+		c.emit(OpGetOuter, depth, sym.Index)
+	default:
+		return c.newError("too deep nesting of functions")
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileIf(exp *ast.IfExpression) error {
+	if err := c.compileExpression(exp.Condition); err != nil {
+		return err
+	}
+	jumpIfFalse := c.emit(OpJumpIfFalse, 0, int(CondIf))
+
+	if err := c.compileBranch(exp.Consequence); err != nil {
+		return err
+	}
+	jump := c.emit(OpJump, 0)
+
+	if err := c.patchJump(jumpIfFalse, len(c.scope().instructions)); err != nil {
+		return err
+	}
+
+	if err := c.compileBranch(exp.Alternative); err != nil {
+		return err
+	}
+
+	return c.patchJump(jump, len(c.scope().instructions))
+}
+
+func (c *Compiler) compileFunction(exp *ast.FunctionLiteral) error {
+	c.scopes = append(c.scopes, &compilationScope{})
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+
+	for _, p := range exp.Parameters {
+		c.symbols.Define(p.Value)
+	}
+	c.hoist(exp.Body.Statements, true)
+
+	if err := c.compileBlockValue(exp.Body); err != nil {
+		return err
+	}
+	c.emit(OpReturnValue)
+
+	scope := c.scope()
+	symbols := c.symbols
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbols = c.symbols.Outer
+
+	fn := &object.CompiledFunction{
+		Instructions:  scope.instructions,
+		Positions:     scope.positions,
+		NumLocals:     len(symbols.Names()),
+		NumParameters: len(exp.Parameters),
+		LocalNames:    symbols.Names(),
+		Source:        (&object.Function{Parameters: exp.Parameters, Body: exp.Body}).ToString(),
+	}
+
+	id, err := c.addConstant(fn)
+	if err != nil {
+		return err
+	}
+	c.emit(OpClosure, id)
+
+	return nil
+}
+
+func (c *Compiler) scope() *compilationScope {
+	return c.scopes[len(c.scopes)-1]
+}
+
+// returns innermost loop of current function, nil if there is no loop
+func (c *Compiler) loop() *loop {
+	loops := c.scope().loops
+	if len(loops) == 0 {
+		return nil
+	}
+
+	return loops[len(loops)-1]
+}
+
+func (c *Compiler) addConstant(obj object.Object) (int, error) {
+	if len(c.constants) > maxOperand(2) {
+		return 0, c.newError("too many constants")
+	}
+
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1, nil
+}
+
+func (c *Compiler) emitConstant(obj object.Object) error {
+	id, err := c.addConstant(obj)
+	if err != nil {
+		return err
+	}
+
+	c.emit(OpConstant, id)
+	return nil
+}
+
+// returns position of emitted instruction
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+	scope := c.scope()
+	pos := len(scope.instructions)
+
+	if n := len(scope.positions); n == 0 || scope.positions[n-1].Pos != c.pos {
+		scope.positions = append(scope.positions, object.Position{Offset: pos, Pos: c.pos})
+	}
+
+	scope.instructions = append(scope.instructions, Make(op, operands...)...)
+	return pos
+}
+
+// sets target of jump instruction at pos
+func (c *Compiler) patchJump(pos int, target int) error {
+	if target > maxOperand(2) {
+		return c.newError("function is too long")
+	}
+
+	binary.BigEndian.PutUint16(c.scope().instructions[pos+1:], uint16(target))
+	return nil
+}
@@ -0,0 +1,169 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/botscubes/bql/internal/lexer"
+	"github.com/botscubes/bql/internal/object"
+	"github.com/botscubes/bql/internal/parser"
+	"github.com/botscubes/bql/internal/token"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpPop, []int{}, []byte{byte(OpPop)}},
+		{OpGetOuter, []int{2, 258}, []byte{byte(OpGetOuter), 2, 1, 2}},
+	}
+
+	for _, test := range tests {
+		ins := Make(test.op, test.operands...)
+		if string(ins) != string(test.expected) {
+			t.Errorf("wrong instruction. got: %v expected: %v", ins, test.expected)
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	var ins Instructions
+	ins = append(ins, Make(OpConstant, 1)...)
+	ins = append(ins, Make(OpGetOuter, 1, 2)...)
+	ins = append(ins, Make(OpReturnValue)...)
+
+	expected := "0000 OpConstant 1\n0003 OpGetOuter 1 2\n0007 OpReturnValue\n"
+	if ins.String() != expected {
+		t.Errorf("wrong instructions string. got: %q expected: %q", ins.String(), expected)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Instructions
+	}{
+		{"1 + 2", []Instructions{
+			Make(OpConstant, 0),
+			Make(OpConstant, 1),
+			Make(OpInfix, 0),
+			Make(OpPop),
+		}},
+		{"x = 1; x", []Instructions{
+			Make(OpConstant, 0),
+			Make(OpSetGlobal, 0),
+			Make(OpNil),
+			Make(OpPop),
+			Make(OpGetGlobal, 0),
+			Make(OpPop),
+		}},
+		{"if (true) { 1 }", []Instructions{
+			Make(OpTrue),
+			Make(OpJumpIfFalse, 11, int(CondIf)),
+			Make(OpConstant, 0),
+			Make(OpJump, 12),
+			Make(OpNull),
+			Make(OpPop),
+		}},
+		{"while (true) { break }", []Instructions{
+			Make(OpTrue),
+			Make(OpJumpIfFalse, 11, int(CondWhile)),
+			Make(OpJump, 11),
+			Make(OpJump, 0),
+			Make(OpNil),
+			Make(OpPop),
+		}},
+	}
+
+	for _, test := range tests {
+		bc := compile(t, test.input)
+
+		var expected Instructions
+		for _, ins := range test.expected {
+			expected = append(expected, ins...)
+		}
+
+		if string(bc.Main.Instructions) != string(expected) {
+			t.Errorf("wrong instructions in test: %q\ngot:\n%s\nexpected:\n%s", test.input, Instructions(bc.Main.Instructions), expected)
+		}
+	}
+}
+
+func TestCompileFunction(t *testing.T) {
+	bc := compile(t, "a = 1; f = fn(x) { g = fn() { x + a }; y = 2 }")
+
+	// constants: 1, inner function, 2, outer function
+	if len(bc.Constants) != 4 {
+		t.Fatalf("wrong number of constants. got: %d expected: 4", len(bc.Constants))
+	}
+
+	inner := bc.Constants[1].(*object.CompiledFunction)
+	expected := string(Make(OpGetOuter, 1, 0)) + string(Make(OpGetGlobal, 0)) + string(Make(OpInfix, 0)) + string(Make(OpReturnValue))
+	if string(inner.Instructions) != expected {
+		t.Errorf("wrong instructions of inner function:\n%s", Instructions(inner.Instructions))
+	}
+
+	outer := bc.Constants[3].(*object.CompiledFunction)
+	if outer.NumLocals != 3 || outer.NumParameters != 1 {
+		t.Errorf("wrong locals of outer function. got: %d, %d expected: 3, 1", outer.NumLocals, outer.NumParameters)
+	}
+
+	expected = string(Make(OpSetDynamic, 0, 2, 3))
+	if !strings.Contains(string(outer.Instructions), expected) {
+		t.Errorf("no OpSetDynamic of y in outer function:\n%s", Instructions(outer.Instructions))
+	}
+
+	// g and y are assigned in function, globals with the same names can exist at runtime
+	if strings.Join(bc.GlobalNames, " ") != "a f g y" {
+		t.Errorf("wrong global names: %v", bc.GlobalNames)
+	}
+}
+
+// variables of function body are defined before compilation of body, nested function uses them as outer variables
+func TestCompileHoisting(t *testing.T) {
+	bc := compile(t, "f = fn() { g = fn() { q }; const q = 1; g() }")
+
+	inner := bc.Constants[0].(*object.CompiledFunction)
+	expected := string(Make(OpGetDynamic, 1, 1, 2)) + string(Make(OpReturnValue))
+	if string(inner.Instructions) != expected {
+		t.Errorf("wrong instructions of inner function:\n%s", Instructions(inner.Instructions))
+	}
+
+	outer := bc.Constants[2].(*object.CompiledFunction)
+	expected = string(Make(OpDeclareLocal, 1, 1))
+	if !strings.Contains(string(outer.Instructions), expected) {
+		t.Errorf("no OpDeclareLocal of q in outer function:\n%s", Instructions(outer.Instructions))
+	}
+}
+
+func TestPositions(t *testing.T) {
+	bc := compile(t, "x = 1\ny = x + q")
+
+	// OpGetGlobal of q
+	ip := len(Make(OpConstant, 0)) + len(Make(OpSetGlobal, 0)) + len(Make(OpNil)) + len(Make(OpPop)) +
+		len(Make(OpGetGlobal, 0))
+	pos := bc.Main.PosAt(ip)
+	if pos != (token.Pos{Line: 2, Offset: 8}) {
+		t.Errorf("wrong position. got: %+v expected: 2:8", pos)
+	}
+}
+
+func compile(t *testing.T, input string) *Bytecode {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	bc, err := Compile(program)
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+
+	return bc
+}
@@ -0,0 +1,160 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope  SymbolScope = "LOCAL"
+)
+
+// constants are checked at runtime: as in evaluator, variable becomes constant when const is executed
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+
+	// variable of function which is created by assignment to unknown name. As object.Env.Assign,
+	// assignment changes global variable with the same name (index Global) if it exists at runtime
+	// (variable of context, host value or variable of top level code), otherwise local variable.
+	// Reading returns local variable if it is set, otherwise global
+	Dynamic bool
+	Global  int
+}
+
+// table of variables of function, of block with declarations (or of program for global table)
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store map[string]Symbol
+	names []string
+	block bool
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol)}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewEnclosedSymbolTable(outer)
+	s.block = true
+	return s
+}
+
+// returns symbol of variable of this table, new symbol is created if it does not exist
+func (s *SymbolTable) Define(name string) Symbol {
+	if sym, ok := s.store[name]; ok {
+		return sym
+	}
+
+	sym := Symbol{Name: name, Scope: LocalScope, Index: len(s.names)}
+	if s.Outer == nil {
+		sym.Scope = GlobalScope
+	}
+
+	s.store[name] = sym
+	s.names = append(s.names, name)
+	return sym
+}
+
+// defines variable assigned or declared in function or block before compilation of its code,
+// so nested function created before assignment uses variable of this scope (as closure in evaluator).
+// Variable of outer function with the same name is not hidden
+func (s *SymbolTable) Hoist(name string) {
+	if sym, _, ok := s.lookup(name); ok && sym.Scope != GlobalScope {
+		return
+	}
+
+	s.defineDynamic(name)
+}
+
+// returns symbol for assignment: the nearest defined variable of functions and blocks,
+// otherwise dynamic variable of current function (global variable for top level code)
+func (s *SymbolTable) ResolveAssign(name string) (Symbol, int) {
+	sym, depth, ok := s.lookup(name)
+	if ok && sym.Scope != GlobalScope {
+		return sym, depth
+	}
+
+	depth = 0
+	table := s
+	for table.block {
+		table = table.Outer
+		depth++
+	}
+
+	return table.defineDynamic(name), depth
+}
+
+// defines variable of this table which falls back to global variable until it is set,
+// global variable is known only at runtime. In global table defines global variable
+func (s *SymbolTable) defineDynamic(name string) Symbol {
+	if s.Outer == nil {
+		return s.Define(name)
+	}
+
+	sym := s.Define(name)
+	if sym.Dynamic {
+		return sym
+	}
+
+	sym.Dynamic = true
+	sym.Global = s.root().Define(name).Index
+	s.store[name] = sym
+
+	return sym
+}
+
+// global table
+func (s *SymbolTable) root() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+
+	return s
+}
+
+func (s *SymbolTable) lookup(name string) (Symbol, int, bool) {
+	if sym, ok := s.store[name]; ok {
+		return sym, 0, true
+	}
+
+	if s.Outer == nil {
+		return Symbol{}, 0, false
+	}
+
+	sym, depth, ok := s.Outer.lookup(name)
+	if !ok || sym.Scope == GlobalScope {
+		return sym, 0, ok
+	}
+
+	return sym, depth + 1, true
+}
+
+// returns symbol and number of scopes (functions and blocks) between current scope and scope where variable is defined.
+// Unknown variables are global: they can be defined in environment, builtins or later in code
+func (s *SymbolTable) Resolve(name string) (Symbol, int) {
+	if sym, ok := s.store[name]; ok {
+		return sym, 0
+	}
+
+	if s.Outer == nil {
+		return s.Define(name), 0
+	}
+
+	sym, depth := s.Outer.Resolve(name)
+	if sym.Scope == GlobalScope {
+		return sym, 0
+	}
+
+	return sym, depth + 1
+}
+
+func (s *SymbolTable) Names() []string {
+	return s.names
+}
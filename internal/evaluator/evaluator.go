@@ -1,20 +1,23 @@
 package evaluator
 
 import (
-	"fmt"
+	"context"
+	"strings"
 
 	"github.com/botscubes/bql/internal/ast"
 	"github.com/botscubes/bql/internal/object"
 )
 
 var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
+	TRUE     = object.TRUE
+	FALSE    = object.FALSE
+	NULL     = object.NULL
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
 func newError(formating string, parameters ...any) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(formating, parameters...)}
+	return object.NewError(formating, parameters...)
 }
 
 func isError(obj object.Object) bool {
@@ -24,27 +27,108 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func boolToBooleanObj(b bool) *object.Boolean {
-	if b {
-		return TRUE
-	}
+type Evaluator struct {
+	ctx    context.Context
+	limits object.Limits
+
+	steps int
+	depth int
 
-	return FALSE
+	limitErr *object.LimitError
 }
 
+// ctx - evaluation is stopped when ctx is done (deadline, cancel)
+func New(ctx context.Context, limits object.Limits) *Evaluator {
+	return &Evaluator{
+		ctx:    ctx,
+		limits: limits,
+	}
+}
+
+// evaluates node without limits
 func Eval(n ast.Node, env *object.Env) object.Object {
+	return New(context.Background(), object.Limits{}).Eval(n, env)
+}
+
+// returns *object.LimitError if evaluation was stopped by limit, otherwise nil
+func (e *Evaluator) Err() error {
+	if e.limitErr != nil {
+		return e.limitErr
+	}
+
+	return nil
+}
+
+func (e *Evaluator) limitExceeded(err *object.LimitError) *object.Error {
+	e.limitErr = err
+	return newError("%s", err.Error())
+}
+
+func (e *Evaluator) step() *object.Error {
+	e.steps++
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		return e.limitExceeded(&object.LimitError{Limit: object.STEPS_LIMIT, Max: e.limits.MaxSteps})
+	}
+
+	select {
+	case <-e.ctx.Done():
+		return e.limitExceeded(&object.LimitError{Limit: object.TIMEOUT_LIMIT, Err: e.ctx.Err()})
+	default:
+	}
+
+	return nil
+}
+
+// returns error if length of string, array or hash map exceeds limit, otherwise obj
+func (e *Evaluator) checkAlloc(obj object.Object) object.Object {
+	if err := e.limits.CheckAlloc(obj); err != nil {
+		return e.limitExceeded(err)
+	}
+
+	return obj
+}
+
+// applies operator, result exceeding allocation limit is not built
+func (e *Evaluator) infixOp(op string, left, right object.Object) object.Object {
+	if err := e.limits.CheckInfixAlloc(op, left, right); err != nil {
+		return e.limitExceeded(err)
+	}
+
+	return e.checkAlloc(e.limits.InfixOp(op, left, right))
+}
+
+func (e *Evaluator) Eval(n ast.Node, env *object.Env) object.Object {
+	if n == nil {
+		return nil
+	}
+
+	result := e.eval(n, env)
+
+	// error gets position of the innermost node
+	if err, ok := result.(*object.Error); ok && err.Pos.Line == 0 {
+		err.Pos = n.Pos()
+	}
+
+	return result
+}
+
+func (e *Evaluator) eval(n ast.Node, env *object.Env) object.Object {
+	if err := e.step(); err != nil {
+		return err
+	}
+
 	switch node := n.(type) {
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return e.evalBlockStatement(node, env)
 
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.Eval(node.Expression, env)
 
 	case *ast.ReturnStatement:
-		val := Eval(node.Value, env)
+		val := e.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
@@ -52,16 +136,67 @@ func Eval(n ast.Node, env *object.Env) object.Object {
 		return &object.Return{Value: val}
 
 	case *ast.AssignStatement:
-		val := Eval(node.Value, env)
+		var cur object.Object
+		if isCompound(node.Operator) {
+			cur = e.Eval(node.Name, env)
+			if isError(cur) {
+				return cur
+			}
+		}
+
+		val := e.Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
 
-		env.Set(node.Name.Value, val)
+		if cur != nil {
+			val = e.evalCompound(node.Operator, cur, val)
+			if isError(val) {
+				return val
+			}
+		}
+
+		if err := env.Assign(node.Name.Value, val); err != nil {
+			return err
+		}
+
+	case *ast.LetStatement:
+		val := e.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		env.Declare(node.Name.Value, val, node.IsConst())
+
+	case *ast.IndexAssignStatement:
+		return e.evalIndexAssign(node, env)
+
+	case *ast.WhileStatement:
+		return e.evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.DecimalLiteral:
+		d, err := object.ParseDecimal(node.Value)
+		if err != nil {
+			return newError("%s", err.Error())
+		}
+
+		return d
+
 	case *ast.Boolean:
 		if node.Value {
 			return TRUE
@@ -69,32 +204,44 @@ func Eval(n ast.Node, env *object.Env) object.Object {
 
 		return FALSE
 
+	case *ast.Null:
+		return NULL
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalPrefixExpression(node.Operator, right)
+		return e.limits.PrefixOp(node.Operator, right)
 
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := e.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
+		// right operand is evaluated only if left is null
+		if node.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+
+			return e.Eval(node.Right, env)
+		}
+
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return e.infixOp(node.Operator, left, right)
 
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
 
 	case *ast.Ident:
 		return evalIdent(node, env)
@@ -103,50 +250,54 @@ func Eval(n ast.Node, env *object.Env) object.Object {
 		return &object.Function{Parameters: node.Parameters, Body: node.Body, Env: env}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		function := e.Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
 
-		args := evalExpressions(node.Arguments, env)
+		args := e.evalExpressions(node.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
 
-		return callFunction(function, args)
+		return e.callFunction(function, args)
 
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
+		elements := e.evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 
-		return &object.Array{Elements: elements}
+		return e.checkAlloc(&object.Array{Elements: elements})
 
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
+	case *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		result, _ := e.evalLink(node.(ast.Expression), env)
+		return result
+
+	case *ast.TemplateLiteral:
+		parts := e.evalExpressions(node.Parts, env)
+		if len(parts) == 1 && isError(parts[0]) {
+			return parts[0]
 		}
 
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
+		if err := e.limits.CheckTemplateAlloc(parts); err != nil {
+			return e.limitExceeded(err)
 		}
 
-		return evalIndexExpression(left, index)
+		return e.checkAlloc(object.TemplateOp(parts))
+
 	case *ast.HashMapLiteral:
-		return evalHashMap(node, env)
+		return e.evalHashMap(node, env)
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Env) object.Object {
+func (e *Evaluator) evalProgram(program *ast.Program, env *object.Env) object.Object {
 	var result object.Object
 
 	for _, stmt := range program.Statements {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 
 		switch r := result.(type) {
 		case *object.Return:
@@ -159,13 +310,14 @@ func evalProgram(program *ast.Program, env *object.Env) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Env) object.Object {
+func (e *Evaluator) evalBlockStatement(block *ast.BlockStatement, env *object.Env) object.Object {
 	var result object.Object
 
 	for _, stmt := range block.Statements {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 		if result != nil {
-			if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		}
@@ -174,128 +326,192 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Env) object.Objec
 	return result
 }
 
-func evalPrefixExpression(op string, right object.Object) object.Object {
-	switch op {
-	case "!":
-		return evalExclOpExpr(right)
-	case "-":
-		return evalMinusPrefixOpExpr(right)
-	default:
-		return newError("unknown operator: %s", op)
-	}
-}
-
-func evalInfixExpression(op string, left object.Object, right object.Object) object.Object {
-	switch {
-	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), op, right.Type())
-	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntInfixExpr(op, left, right)
-	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpr(op, left, right)
-	case op == "==":
-		return boolToBooleanObj(left == right)
-	case op == "!=":
-		return boolToBooleanObj(left != right)
-	case op == "||":
-		return boolToBooleanObj(left.(*object.Boolean).Value || right.(*object.Boolean).Value)
-	case op == "&&":
-		return boolToBooleanObj(left.(*object.Boolean).Value && right.(*object.Boolean).Value)
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+func (e *Evaluator) evalIfExpression(node *ast.IfExpression, env *object.Env) object.Object {
+	condition := e.Eval(node.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if condition != TRUE && condition != FALSE {
+		return newError("non boolean condition in if statement")
+	}
+
+	var result object.Object
+	if condition == TRUE {
+		result = e.Eval(node.Consequence, blockEnv(node.Consequence, env))
+	} else if node.Alternative != nil {
+		result = e.Eval(node.Alternative, blockEnv(node.Alternative, env))
+	}
+
+	// block without value (empty or ends with statement)
+	if result == nil {
+		return NULL
 	}
+
+	return result
 }
 
-func evalExclOpExpr(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
+func (e *Evaluator) evalWhileStatement(node *ast.WhileStatement, env *object.Env) object.Object {
+	for {
+		condition := e.Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if condition != TRUE && condition != FALSE {
+			return newError("non boolean condition in while statement")
+		}
+
+		if condition == FALSE {
+			return nil
+		}
+
+		if result, stop := e.evalLoopBody(node.Body, blockEnv(node.Body, env)); stop {
+			return result
+		}
+	}
+}
+
+func (e *Evaluator) evalForStatement(node *ast.ForStatement, env *object.Env) object.Object {
+	iterable := e.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		// the array can be changed in the body (push), iterate over elements that were before the loop
+		elements := iterable.Elements
+		for id, el := range elements {
+			// variables of loop are new in each iteration
+			iterEnv := object.NewBlockEnv(env)
+			if node.Key != nil {
+				iterEnv.Set(node.Key.Value, &object.Integer{Value: int64(id)})
+			}
+			iterEnv.Set(node.Value.Value, el)
+
+			if result, stop := e.evalLoopBody(node.Body, iterEnv); stop {
+				return result
+			}
+		}
+	case *object.HashMap:
+		for _, pair := range iterable.Ordered() {
+			iterEnv := object.NewBlockEnv(env)
+			if node.Key != nil {
+				iterEnv.Set(node.Key.Value, pair.Key)
+				iterEnv.Set(node.Value.Value, pair.Value)
+			} else {
+				iterEnv.Set(node.Value.Value, pair.Key)
+			}
+
+			if result, stop := e.evalLoopBody(node.Body, iterEnv); stop {
+				return result
+			}
+		}
 	default:
-		return FALSE
+		return newError("iteration not supported: %s", iterable.Type())
 	}
+
+	return nil
 }
 
-func evalMinusPrefixOpExpr(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
-	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
-}
-
-func evalIntInfixExpr(op string, left object.Object, right object.Object) object.Object {
-	lVal := left.(*object.Integer).Value
-	rVal := right.(*object.Integer).Value
-	switch op {
-	case "+":
-		return &object.Integer{Value: lVal + rVal}
-	case "-":
-		return &object.Integer{Value: lVal - rVal}
-	case "*":
-		return &object.Integer{Value: lVal * rVal}
-	case "/":
-		return &object.Integer{Value: lVal / rVal}
-	case "%":
-		return &object.Integer{Value: lVal % rVal}
-	case "==":
-		return boolToBooleanObj(lVal == rVal)
-	case "!=":
-		return boolToBooleanObj(lVal != rVal)
-	case "<":
-		return boolToBooleanObj(lVal < rVal)
-	case ">":
-		return boolToBooleanObj(lVal > rVal)
-	case "<=":
-		return boolToBooleanObj(lVal <= rVal)
-	case ">=":
-		return boolToBooleanObj(lVal >= rVal)
+// evaluates member, index or slice expression, skipped is true if optional link of chain has null operand,
+// then the rest of chain is not evaluated: x?.a.b and x?.a[0] are null if x is null
+func (e *Evaluator) evalLink(n ast.Expression, env *object.Env) (result object.Object, skipped bool) {
+	var left ast.Expression
+	var optional bool
+	switch node := n.(type) {
+	case *ast.MemberExpression:
+		left, optional = node.Left, node.Optional
+	case *ast.IndexExpression:
+		left, optional = node.Left, node.Optional
+	case *ast.SliceExpression:
+		left, optional = node.Left, node.Optional
+	}
+
+	obj, skipped := e.evalChainLeft(left, env)
+	if isError(obj) {
+		return obj, false
+	}
+
+	if skipped || optional && obj == NULL {
+		return NULL, true
+	}
+
+	switch node := n.(type) {
+	case *ast.MemberExpression:
+		return object.MemberOp(obj, node.Property.Value), false
+	case *ast.IndexExpression:
+		index := e.Eval(node.Index, env)
+		if isError(index) {
+			return index, false
+		}
+
+		return object.IndexOp(obj, index), false
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
-	}
-}
-
-func evalStringInfixExpr(op string, left object.Object, right object.Object) object.Object {
-	lVal := left.(*object.String).Value
-	rVal := right.(*object.String).Value
-	switch op {
-	case "+":
-		return &object.String{Value: lVal + rVal}
-	case "==":
-		return boolToBooleanObj(lVal == rVal)
-	case "!=":
-		return boolToBooleanObj(lVal != rVal)
-	case ">":
-		return boolToBooleanObj(lVal > rVal)
-	case "<":
-		return boolToBooleanObj(lVal < rVal)
-	case ">=":
-		return boolToBooleanObj(lVal >= rVal)
-	case "<=":
-		return boolToBooleanObj(lVal <= rVal)
+		return e.evalSlice(n.(*ast.SliceExpression), obj, env), false
+	}
+}
+
+// evaluates left operand of link as Eval, link of the same chain also reports if chain is skipped
+func (e *Evaluator) evalChainLeft(left ast.Expression, env *object.Env) (object.Object, bool) {
+	switch left.(type) {
+	case *ast.MemberExpression, *ast.IndexExpression, *ast.SliceExpression:
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+		return e.Eval(left, env), false
+	}
+
+	if err := e.step(); err != nil {
+		return err, false
+	}
+
+	obj, skipped := e.evalLink(left, env)
+	if err, ok := obj.(*object.Error); ok && err.Pos.Line == 0 {
+		err.Pos = left.Pos()
 	}
+
+	return obj, skipped
 }
 
-func evalIfExpression(node *ast.IfExpression, env *object.Env) object.Object {
-	condition := Eval(node.Condition, env)
-	if isError(condition) {
-		return condition
+func (e *Evaluator) evalSlice(node *ast.SliceExpression, left object.Object, env *object.Env) object.Object {
+	bounds := [2]object.Object{NULL, NULL}
+	for id, exp := range []ast.Expression{node.Low, node.High} {
+		if exp == nil {
+			continue
+		}
+
+		bounds[id] = e.Eval(exp, env)
+		if isError(bounds[id]) {
+			return bounds[id]
+		}
 	}
 
-	if condition != TRUE && condition != FALSE {
-		return newError("non boolean condition in if statement")
+	return e.checkAlloc(object.SliceOp(left, bounds[0], bounds[1]))
+}
+
+// block with declarations has own env
+func blockEnv(block *ast.BlockStatement, env *object.Env) *object.Env {
+	if block.HasDeclarations() {
+		return object.NewBlockEnv(env)
 	}
 
-	if condition == TRUE {
-		return Eval(node.Consequence, env)
-	} else if node.Alternative != nil {
-		return Eval(node.Alternative, env)
-	} else {
-		return NULL
+	return env
+}
+
+// evaluates body of loop, returns true if loop must be stopped (break, return or error)
+func (e *Evaluator) evalLoopBody(body *ast.BlockStatement, env *object.Env) (object.Object, bool) {
+	result := e.Eval(body, env)
+	if result == nil {
+		return nil, false
+	}
+
+	switch result.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return result, true
+	case object.BREAK_OBJ:
+		return nil, true
+	default:
+		return nil, false
 	}
 }
 
@@ -304,18 +520,18 @@ func evalIdent(node *ast.Ident, env *object.Env) object.Object {
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin, ok := object.Builtins[node.Value]; ok {
 		return builtin
 	}
 
 	return newError("identifier not found: " + node.Value)
 }
 
-func evalExpressions(exprs []ast.Expression, env *object.Env) []object.Object {
+func (e *Evaluator) evalExpressions(exprs []ast.Expression, env *object.Env) []object.Object {
 	var result []object.Object
 
 	for _, exp := range exprs {
-		ev := Eval(exp, env)
+		ev := e.Eval(exp, env)
 		if isError(ev) {
 			return []object.Object{ev}
 		}
@@ -324,19 +540,43 @@ func evalExpressions(exprs []ast.Expression, env *object.Env) []object.Object {
 	return result
 }
 
-func callFunction(function object.Object, args []object.Object) object.Object {
+func (e *Evaluator) callFunction(function object.Object, args []object.Object) object.Object {
 	switch fn := function.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments: %d want: %d", len(args), len(fn.Parameters))
+		}
+
+		if maxDepth := e.limits.CallDepth(); maxDepth > 0 && e.depth >= maxDepth {
+			return e.limitExceeded(&object.LimitError{Limit: object.CALL_DEPTH_LIMIT, Max: maxDepth})
+		}
+
+		e.depth++
+		defer func() { e.depth-- }()
+
 		extEnv := extendFuncEnv(fn, args)
-		ev := Eval(fn.Body, extEnv)
+		ev := e.Eval(fn.Body, extEnv)
+		if ev == nil {
+			return NULL
+		}
+
 		return unwrapReturn(ev)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		if err := e.limits.CheckCallAlloc(fn, args); err != nil {
+			return e.limitExceeded(err)
+		}
+
+		return e.checkAlloc(fn.Call(e.call, args...))
 	default:
 		return newError("call not a function: %s", fn.Type())
 	}
 }
 
+// calls function from builtin
+func (e *Evaluator) call(fn object.Object, args ...object.Object) object.Object {
+	return e.callFunction(fn, args)
+}
+
 func extendFuncEnv(fn *object.Function, args []object.Object) *object.Env {
 	env := object.NewEnclosedEnv(fn.Env)
 
@@ -355,65 +595,107 @@ func unwrapReturn(obj object.Object) object.Object {
 	return obj
 }
 
-func evalIndexExpression(left, index object.Object) object.Object {
-	switch {
-	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalArrayIndexExp(left, index)
-	case left.Type() == object.HASH_MAP_OBJ:
-		return evalHashMapIndexExp(left, index)
-	default:
-		return newError("index operator not supported: %s", left.Type())
-	}
+// compound assignment operator: +=, -=, *=, /=, %=
+func isCompound(op string) bool {
+	return op != "" && op != "="
 }
 
-func evalArrayIndexExp(left, index object.Object) object.Object {
-	array := left.(*object.Array)
-	idx := index.(*object.Integer).Value
+// applies operator of compound assignment to current and new values
+func (e *Evaluator) evalCompound(op string, cur, val object.Object) object.Object {
+	return e.infixOp(strings.TrimSuffix(op, "="), cur, val)
+}
 
-	if idx < 0 || idx > int64(len(array.Elements)-1) {
-		return NULL
+func (e *Evaluator) evalIndexAssign(node *ast.IndexAssignStatement, env *object.Env) object.Object {
+	var (
+		left  object.Object
+		index object.Object // nil for m.name
+		name  string
+	)
+
+	switch target := node.Target.(type) {
+	case *ast.MemberExpression:
+		left = e.Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		name = target.Property.Value
+
+	case *ast.IndexExpression:
+		left = e.Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		index = e.Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+	}
+
+	var cur object.Object
+	if isCompound(node.Operator) {
+		if index == nil {
+			cur = object.MemberOp(left, name)
+		} else {
+			cur = object.IndexOp(left, index)
+		}
+
+		if isError(cur) {
+			return cur
+		}
+	}
+
+	val := e.Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if cur != nil {
+		val = e.evalCompound(node.Operator, cur, val)
+		if isError(val) {
+			return val
+		}
+	}
+
+	var err object.Object
+	if index == nil {
+		err = object.SetMemberOp(left, name, val)
+	} else {
+		err = object.SetIndexOp(left, index, val)
 	}
 
-	return array.Elements[idx]
+	if err != nil {
+		return err
+	}
+
+	if err := e.checkAlloc(left); isError(err) {
+		return err
+	}
+
+	return nil
 }
 
-func evalHashMap(node *ast.HashMapLiteral, env *object.Env) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+func (e *Evaluator) evalHashMap(node *ast.HashMapLiteral, env *object.Env) object.Object {
+	hashMap := object.NewHashMap(len(node.Keys))
 
-	for knode, vnode := range node.Pairs {
-		key := Eval(knode, env)
+	for _, knode := range node.Keys {
+		key := e.Eval(knode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := key.(object.Hashable); !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(vnode, env)
+		value := e.Eval(node.Pairs[knode], env)
 		if isError(value) {
 			return value
 		}
 
-		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
-	}
-
-	return &object.HashMap{Pairs: pairs}
-}
-
-func evalHashMapIndexExp(hashMap, index object.Object) object.Object {
-	hashObject := hashMap.(*object.HashMap)
-
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
-	}
-
-	pair, ok := hashObject.Pairs[key.HashKey()]
-	if !ok {
-		return NULL
+		hashMap.Set(key, value)
 	}
 
-	return pair.Value
+	return e.checkAlloc(hashMap)
 }
@@ -1,11 +1,23 @@
 package evaluator
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/botscubes/bql/internal/ast"
+	"github.com/botscubes/bql/internal/compiler"
 	"github.com/botscubes/bql/internal/lexer"
 	"github.com/botscubes/bql/internal/object"
 	"github.com/botscubes/bql/internal/parser"
+	"github.com/botscubes/bql/internal/token"
+	"github.com/botscubes/bql/internal/vm"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -31,11 +43,35 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		testInteger(t, ev, test.expected)
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.25", -2.25},
+		{"1e-3", 0.001},
+		{"1.5 + 2.5", 4},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"10 / 4.0", 2.5},
+		{"2.5 * 2", 5},
+		{"5.5 % 2", 1.5},
+		{"3 - 4.5", -1.5},
+		{"x = 0.1; y = 0.2; x * 10 + y * 10", 3},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		testFloat(t, ev, test.expected)
+	}
+}
+
 func TestEvalBooleanExpresion(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -60,6 +96,12 @@ func TestEvalBooleanExpresion(t *testing.T) {
 		{"(2 > 1) == true", true},
 		{"(2 < 1) == false", true},
 		{"(1 <= 1) == false", false},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"0.1 >= 0.2", false},
+		{"2.5 <= 2.5", true},
 		{"true || false", true},
 		{"true && false", false},
 		{"true && true", true},
@@ -79,7 +121,7 @@ func TestEvalBooleanExpresion(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		testBoolean(t, ev, test.expected, test.input)
 	}
 }
@@ -96,7 +138,7 @@ func TestExclaminationOperator(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		testBoolean(t, ev, test.expected, test.input)
 	}
 }
@@ -119,7 +161,7 @@ func TestIfElseExpression(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		intVal, ok := test.expected.(int)
 		if ok {
 			testInteger(t, ev, int64(intVal))
@@ -156,7 +198,7 @@ if (0 == 0) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		testInteger(t, ev, test.expected)
 	}
 }
@@ -172,6 +214,9 @@ func TestErrorHandling(t *testing.T) {
 		{"-true", "unknown operator: -BOOLEAN"},
 		{"true + 3", "type mismatch: BOOLEAN + INTEGER"},
 		{"3 * false", "type mismatch: INTEGER * BOOLEAN"},
+		{"1.5 + true", "type mismatch: FLOAT + BOOLEAN"},
+		{`"a" + 1.5`, "type mismatch: STRING + FLOAT"},
+		{"-(1.5 == 1.5)", "unknown operator: -BOOLEAN"},
 		{`"Hello" * 3`, "type mismatch: STRING * INTEGER"},
 		{`"Hello" * "Earth"`, "unknown operator: STRING * STRING"},
 		{"if (3) { 1 }", "non boolean condition in if statement"},
@@ -187,10 +232,14 @@ if (1 > 0) {
 		{"ijk", "identifier not found: ijk"},
 		{"true[1]", "index operator not supported: BOOLEAN"},
 		{"123[123]", "index operator not supported: INTEGER"},
+		{"while (1) { 2 }", "non boolean condition in while statement"},
+		{"for (x in 5) { x }", "iteration not supported: INTEGER"},
+		{"while (true) { x = y }", "identifier not found: y"},
+		{"f = fn(x, y) { x }; f(1)", "wrong number of arguments: 1 want: 2"},
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		err, ok := ev.(*object.Error)
 		if !ok {
 			t.Errorf("non error object returned: %T - %+v", ev, ev)
@@ -202,6 +251,32 @@ if (1 > 0) {
 	}
 }
 
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected token.Pos
+	}{
+		{"1 + true", token.Pos{Line: 1, Offset: 0}},
+		{"x = 1\ny = x + q", token.Pos{Line: 2, Offset: 8}},
+		{"f = fn(a) {\n\treturn -a\n}\nf(true)", token.Pos{Line: 2, Offset: 8}},
+		{"[1, 2][0](1)", token.Pos{Line: 1, Offset: 0}},
+		{"map([1], fn(x) {\n\treturn x + true\n})", token.Pos{Line: 2, Offset: 8}},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		err, ok := ev.(*object.Error)
+		if !ok {
+			t.Errorf("non error object returned: %T - %+v", ev, ev)
+			continue
+		}
+
+		if err.Pos != test.expected {
+			t.Errorf("wrong error position. got: %+v expected: %+v in test: %q", err.Pos, test.expected, test.input)
+		}
+	}
+}
+
 func TestEvalAssignExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -215,7 +290,7 @@ func TestEvalAssignExpression(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		testInteger(t, ev, test.expected)
 	}
 }
@@ -242,7 +317,74 @@ a(4)
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
+		testInteger(t, ev, test.expected)
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"i = 0; while (i < 10) { i = i + 1 }; i", 10},
+		{"i = 0; while (false) { i = i + 1 }; i", 0},
+		{"i = 0; while (true) { i = i + 1; if (i == 5) { break } }; i", 5},
+		{`
+i = 0
+s = 0
+while (i < 10) {
+	i = i + 1
+	if (i % 2 == 0) {
+		continue
+	}
+	s = s + i
+}
+s`, 25},
+		{"s = 0; for (x in [1, 2, 3]) { s = s + x }; s", 6},
+		{"s = 0; for (i, x in [5, 6, 7]) { s = s + i * x }; s", 20},
+		{"s = 0; for (x in []) { s = s + 1 }; s", 0},
+		{"s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break }; s = s + x }; s", 3},
+		{"s = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { continue }; s = s + x }; s", 7},
+		{`s = 0; for (k, v in {"a": 1, "b": 2}) { s = s + v }; s`, 3},
+		{`s = 0; for (k in {1: 0, 2: 0}) { s = s + k }; s`, 3},
+		{"a = [1, 2]; for (x in a) { push(a, x) }; len(a)", 4},
+		{`
+s = 0
+for (row in [[1, 2], [3, 4]]) {
+	for (x in row) {
+		if (x == 2) {
+			break
+		}
+		s = s + x
+	}
+}
+s`, 8},
+		{`
+f = fn(arr) {
+	for (x in arr) {
+		if (x > 2) {
+			return x
+		}
+	}
+	return -1
+}
+f([1, 2, 3, 4]) + f([1])`, 2},
+		{`
+f = fn(n) {
+	i = 0
+	while (true) {
+		i = i + 1
+		if (i == n) {
+			return i * 10
+		}
+	}
+}
+f(3)`, 30},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
 		testInteger(t, ev, test.expected)
 	}
 }
@@ -254,10 +396,20 @@ func TestEvalStringExpression(t *testing.T) {
 	}{
 		{`"Hello Earth"`, "Hello Earth"},
 		{`"Hello" + " " + "Earth"`, "Hello Earth"},
+		{`"a\tb\n"`, "a\tb\n"},
+		{`"\u{41}\"q\"\\"`, `A"q"\`},
+		{`"привет"[0]`, "п"},
+		{`"привет"[5]`, "т"},
+		{`"привет"[1:4]`, "рив"},
+		{`"привет"[:2] + "!"`, "пр!"},
+		{`"привет"[3:]`, "вет"},
+		{`"abc"[-5:10]`, "abc"},
+		{`"abc"[2:1]`, ""},
+		{`s = "мир"; s[:]`, "мир"},
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		res, ok := ev.(*object.String)
 		if !ok {
 			t.Errorf("obj not String got:%+v", ev)
@@ -270,10 +422,69 @@ func TestEvalStringExpression(t *testing.T) {
 	}
 }
 
+func TestStringUnicode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len("привет")`, "6"},
+		{`len("😀a")`, "2"},
+		{`"abc"[3]`, "Null"},
+		{`"abc"[-1]`, "Null"},
+		{`a = [1, 2, 3, 4]; [a[1:3], a[:1], a[2:], a[5:]]`, "[[2, 3], [1], [3, 4], []]"},
+		{`a = [1, 2]; b = a[:]; b[0] = 5; a[0]`, "1"},
+		{`n = null; n?[1:2]`, "Null"},
+		{`"abc"["a"]`, "error: index operator not supported: STRING"},
+		{`"abc"["a":]`, "error: slice index must be INTEGER, got: STRING"},
+		{`{}[1:2]`, "error: slice operator not supported: HASH_MAP"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"`plain`", "plain"},
+		{"``", ""},
+		{"name = \"Bob\"; n = 3; `Hello, ${name}! You have ${n} items`", "Hello, Bob! You have 3 items"},
+		{"`${1.5 * 2} ${true} ${null} ${[1, \"a\"]}`", "3 true Null [1, a]"},
+		{"n = 2; `${`${n}${n}` + \"!\"}`", "22!"},
+		{"f = fn(x) { x * 2 }; `res: ${f(21)}`", "res: 42"},
+		{"m = {\"a\": {\"b\": 7}}; `${m.a.b}/${m[\"a\"][\"b\"]}`", "7/7"},
+		{"`line\\n\\${x}`", "line\n${x}"},
+		{"`${q}`", "error: identifier not found: q"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %q expected: %q in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
 func TestArray(t *testing.T) {
 	input := "[1, 2, -33, 5+5, 1 + 2 + 3 + 4 * 5]"
 
-	ev := getEvaluated(input)
+	ev := getEvaluated(t, input)
 	res, ok := ev.(*object.Array)
 	if !ok {
 		t.Errorf("obj not Array got:%+v", ev)
@@ -307,7 +518,7 @@ func TestArrayIndexExpression(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		intVal, ok := test.expected.(int)
 		if ok {
 			testInteger(t, ev, int64(intVal))
@@ -328,7 +539,7 @@ func TestHashMap(t *testing.T) {
 		false: 0
 	}`
 
-	ev := getEvaluated(input)
+	ev := getEvaluated(t, input)
 	res, ok := ev.(*object.HashMap)
 	if !ok {
 		t.Fatalf("non HashMap returned: %T - %+v", ev, ev)
@@ -369,10 +580,18 @@ func TestHashMapIndexExpression(t *testing.T) {
 		{`{true: 5}[true]`, 5},
 		{`{false: -1}[false]`, -1},
 		{`y = "key"; {"key": 0}[y]`, 0},
+		// whole float and decimal keys are the same as integer keys
+		{`{1: 3}[1.0]`, 3},
+		{`{2.0: 4}[2]`, 4},
+		{`{0: 6}[-0.0]`, 6},
+		{`{1: 7}[1.00d]`, 7},
+		{`m = {}; m[3.0] = 1; m[3] = 8; len(m) * m[3.0d]`, 8},
+		{`{1.5: 1}[1]`, nil},
+		{`{1: 1}[1.5]`, nil},
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		intVal, ok := test.expected.(int)
 		if ok {
 			testInteger(t, ev, int64(intVal))
@@ -417,7 +636,7 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		ev := getEvaluated(test.input)
+		ev := getEvaluated(t, test.input)
 		switch ex := test.expected.(type) {
 		case int:
 			testInteger(t, ev, int64(ex))
@@ -464,12 +683,1089 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
-func getEvaluated(input string) object.Object {
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`join(split("a,b,,c", ","), "|")`, "a|b||c"},
+		{`join(split("абв", ""), "|")`, "а|б|в"},
+		{`join(["a", 1, true, null], "-")`, "a-1-true-Null"},
+		{`join(["a", "b"])`, "ab"},
+		{`trim("  a b \n")`, "a b"},
+		{`trim("--a-", "-")`, "a"},
+		{`upper("abc") + lower("ДЕФ")`, "ABCдеф"},
+		{`[contains("hello", "ell"), startsWith("hello", "he"), endsWith("hello", "lo"), endsWith("hello", "he")]`, "[true, true, true, false]"},
+		{`[indexOf("привет", "вет"), indexOf("abc", "d")]`, "[3, -1]"},
+		{`replace("a.b.c", ".", "/")`, "a/b/c"},
+		{`join([substr("привет", 2), substr("привет", 1, 3), substr("abc", 1, 10)], "|")`, "ивет|рив|bc"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`join([padLeft("7", 3, "0"), padRight("ab", 5, "xy"), padLeft("abc", 2), padLeft("a", 3)], "|")`, "007|abxyx|abc|  a"},
+		{`format("{} + {} = {}", 1, 2, 1 + 2)`, "1 + 2 = 3"},
+		{`format("{1} {0} {{}} {}", "a", "b")`, "b a {} a"},
+		{`upper()`, "error: wrong number of arguments: 0 want: 1"},
+		{`split("a")`, "error: wrong number of arguments: 1 want: 2"},
+		{`substr("a", 1, 2, 3)`, "error: wrong number of arguments: 4 want: 2-3"},
+		{`format()`, "error: wrong number of arguments: 0 want at least: 1"},
+		{`upper(1)`, "error: argument must be STRING, got: INTEGER"},
+		{`repeat("a", "b")`, "error: second argument must be INTEGER, got: STRING"},
+		{`repeat("a", -1)`, "error: count must not be negative: -1"},
+		{`repeat("ab", 1000000000000)`, "error: result is too long"},
+		{`padLeft("a", 3, "")`, "error: pad must not be empty"},
+		{`format("{} {}", 1)`, "error: format: no argument for placeholder 1"},
+		{`format("{x}", 1)`, "error: format: invalid placeholder: {x}"},
+		{`format("{", 1)`, "error: format: unclosed {"},
+		{`format("}", 1)`, "error: format: unexpected }"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`k = 10; map([1, 2], fn(x) { return x + k })`, "[11, 12]"},
+		{`map(["a", "b"], upper)`, "[A, B]"},
+		{`filter(range(10), fn(x) { x % 3 == 0 })`, "[0, 3, 6, 9]"},
+		{`reduce([1, 2, 3, 4], fn(acc, x) { acc + x })`, "10"},
+		{`reduce([], fn(acc, x) { acc + x }, 5)`, "5"},
+		{`[find([1, 5, 8], fn(x) { x > 3 }), find([1], fn(x) { x > 3 })]`, "[5, Null]"},
+		{`[any([1, 2], fn(x) { x > 1 }), any([], fn(x) { true }), all([1, 2], fn(x) { x > 1 }), all([], fn(x) { false })]`, "[true, false, false, true]"},
+		{`a = [3, 1, 2]; [sort(a), a]`, "[[1, 2, 3], [3, 1, 2]]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([1, 2.5, 0])`, "[0, 1, 2.5]"},
+		{`sort([3, 1, 2], fn(a, b) { b - a })`, "[3, 2, 1]"},
+		{`sort([{"n": 2}, {"n": 1}], fn(a, b) { a.n - b.n })`, "[[n: 1], [n: 2]]"},
+		{`[reverse([1, 2, 3]), reverse("абв")]`, "[[3, 2, 1], вба]"},
+		{`[slice([1, 2, 3, 4], 1, 3), slice([1, 2, 3], 1), slice("привет", 0, 3)]`, "[[2, 3], [2, 3], при]"},
+		{`concat([1], [], [2, 3])`, "[1, 2, 3]"},
+		{`unique([1, 2, 1, 1.0, "a", "a", true, true])`, "[1, 2, a, true]"},
+		{`[range(3), range(1, 3), range(0, 10, 4), range(3, 0, -1), range(3, 0)]`, "[[0, 1, 2], [1, 2], [0, 4, 8], [3, 2, 1], []]"},
+		{`[indexOf([1, "a", 2], 2), indexOf([1], "1"), indexOf("abc", "c")]`, "[2, -1, 2]"},
+		{`[contains([1, [2]], 1), contains([1], 2), contains("abc", "bc")]`, "[true, false, true]"},
+		{`calls = 0; any([1, 2, 3], fn(x) { calls = calls + 1; x == 2 }); calls`, "2"},
+		{`map([1], fn(x, y) { x })`, "error: wrong number of arguments: 1 want: 2"},
+		{`map([1], 1)`, "error: second argument must be FUNCTION, got: INTEGER"},
+		{`map([1, "a"], fn(x) { x + 1 })`, "error: type mismatch: STRING + INTEGER"},
+		{`filter([1], fn(x) { x })`, "error: function must return BOOLEAN, got: INTEGER"},
+		{`reduce([], fn(acc, x) { acc + x })`, "error: reduce of empty array without initial value"},
+		{`sort([1, "a"])`, "error: type mismatch: STRING < INTEGER"},
+		{`sort([1, 2], fn(a, b) { true })`, "error: comparator must return INTEGER or FLOAT, got: BOOLEAN"},
+		{`slice({}, 1)`, "error: first argument must be ARRAY or STRING, got: HASH_MAP"},
+		{`concat([1], 2)`, "error: second argument must be ARRAY, got: INTEGER"},
+		{`range(1, 2, 0)`, "error: step must not be zero"},
+		{`range(100000000000)`, "error: range is too large: 100000000000 elements"},
+		{`indexOf("abc", 1)`, "error: second argument must be STRING, got: INTEGER"},
+		{`contains(1, 1)`, "error: first argument must be ARRAY or STRING, got: INTEGER"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestHashMapBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, 3: 3}`, "[b: 1, a: 2, 3: 3]"},
+		{`m = {"b": 1}; m.a = 2; m["b"] = 3; m`, "[b: 3, a: 2]"},
+		{`r = []; for (k, v in {"z": 1, "y": 2, "x": 3}) { push(r, k) }; r`, "[z, y, x]"},
+		{`[len({}), len({"a": 1, "b": 2})]`, "[0, 2]"},
+		{`m = {"b": 1, "a": 2}; [keys(m), values(m), entries(m)]`, "[[b, a], [1, 2], [[b, 1], [a, 2]]]"},
+		{`m = {"a": 1, 2: null}; [has(m, "a"), has(m, 2), has(m, "c")]`, "[true, true, false]"},
+		{`m = {"a": 1, "b": 2, "c": 3}; delete(m, "b"); delete(m, "x"); m.b = 4; m`, "[a: 1, c: 3, b: 4]"},
+		{`a = {"x": 1, "y": 2}; b = merge(a, {"y": 3, "z": 4}); [a, b]`, "[[x: 1, y: 2], [x: 1, y: 3, z: 4]]"},
+		{`keys([])`, "error: argument must be HASH_MAP, got: ARRAY"},
+		{`has({}, [])`, "error: unusable as hash key: ARRAY"},
+		{`merge({}, 1)`, "error: second argument must be HASH_MAP, got: INTEGER"},
+		{`delete({})`, "error: wrong number of arguments: 1 want: 2"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[test("+7 999 123-45-67", "^\\+7[0-9 -]{10,}$"), test("abc", "^\\d+$")]`, "[true, false]"},
+		{`match("order #123-45", "#(\\d+)-(\\d+)")`, "[#123-45, 123, 45]"},
+		{`match("abc", "(x)?b")`, "[b, Null]"},
+		{`match("abc", "\\d")`, "Null"},
+		{`matchAll("a1 b22 c333", "[a-z](\\d+)")`, "[[a1, 1], [b22, 22], [c333, 333]]"},
+		{`matchAll("abc", "\\d")`, "[]"},
+		{`replaceRegex("2024-01-15", "(\\d+)-(\\d+)-(\\d+)", "$3.$2.$1")`, "15.01.2024"},
+		{`replaceRegex("a  b   c", "\\s+", " ")`, "a b c"},
+		{`join(splitRegex("a, b;c", "[,;]\\s*"), "|")`, "a|b|c"},
+		{`test("a", "(")`, "error: invalid regular expression: error parsing regexp: missing closing ): `(`"},
+		{`test("a")`, "error: wrong number of arguments: 1 want: 2"},
+		{`match(1, "a")`, "error: first argument must be STRING, got: INTEGER"},
+		{`replaceRegex("a", "a", 1)`, "error: third argument must be STRING, got: INTEGER"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestJSONBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`jsonParse("{\"b\": [1, 2.5, null, true], \"a\": {\"c\": \"x\"}}")`, "[a: [c: x], b: [1, 2.5, Null, true]]"},
+		{`jsonParse("9007199254740993")`, "9007199254740993"},
+		{`jsonParse("1.0") + 0.5`, "1.5"},
+		{`jsonParse(" \"строка\" ")`, "строка"},
+		{`jsonParse("[{\"id\": 1}]")[0].id`, "1"},
+		{`jsonStringify({"b": 1, "a": [true, null, 2.5, "x\"y"], 1: {}})`, `{"b":1,"a":[true,null,2.5,"x\"y"],"1":{}}`},
+		{`jsonStringify("<b>")`, `"<b>"`},
+		{`jsonStringify({"a": [1, 2], "b": []}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": []\n}"},
+		{`jsonStringify([1], "\t")`, "[\n\t1\n]"},
+		{`s = "{\"a\":[1,{\"b\":null}]}"; jsonStringify(jsonParse(s)) == s`, "true"},
+		{`jsonParse("{")`, "error: jsonParse: unexpected EOF"},
+		{`jsonParse("[1] 2")`, "error: jsonParse: unexpected data after value"},
+		{`jsonParse(1)`, "error: argument must be STRING, got: INTEGER"},
+		{`jsonStringify(fn(x) { x })`, "error: jsonStringify: unsupported type: FUNCTION"},
+		{`jsonStringify(1, true)`, "error: second argument must be INTEGER or STRING, got: BOOLEAN"},
+		{`jsonStringify(1, 11)`, "error: indent must be from 0 to 10, got: 11"},
+		{`a = []; push(a, a); jsonStringify(a)`, "error: jsonStringify: cyclic value"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestTimeBuiltins(t *testing.T) {
+	clock := func() time.Time { return time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC) }
+	newEnv := func() *object.Env {
+		env := object.NewEnv()
+		env.Set("now", object.NowBuiltin(clock))
+		return env
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`now()`, "2024-01-15T10:30:00Z"},
+		{`t = now(); [t.year, t.month, t.day, t.hour, t.minute, t.second, t.weekday, t.timezone]`, "[2024, 1, 15, 10, 30, 0, 1, UTC]"},
+		{`parseTime("2024-03-10T08:00:00+03:00")`, "2024-03-10T08:00:00+03:00"},
+		{`parseTime("10.03.2024 08:00", "02.01.2006 15:04", "Europe/Moscow")`, "2024-03-10T08:00:00+03:00"},
+		{`formatTime(parseTime("2024-03-10", "date"), "02.01.2006")`, "10.03.2024"},
+		{`formatTime(inTimezone(now(), "Asia/Tokyo"), "datetime")`, "2024-01-15 19:30:00"},
+		{`addDuration(now(), duration("1h30m"))`, "2024-01-15T12:00:00Z"},
+		{`diff(parseTime("2024-01-16", "date"), now())`, "13h30m0s"},
+		{`diff(now(), parseTime("2024-01-16", "date")).hours`, "-13.5"},
+		{`now() + duration("24h") - duration("30m")`, "2024-01-16T10:00:00Z"},
+		{`[duration("1h") * 2, 3 * duration("1m"), duration("1h") / 4, duration("1h") / duration("15m")]`, "[2h0m0s, 3m0s, 15m0s, 4]"},
+		{`t = now(); [t < t + duration("1s"), t == inTimezone(t, "Asia/Tokyo"), duration("1m") > duration("59s")]`, "[true, true, true]"},
+		{`jsonStringify({"at": now(), "in": duration("90s")})`, `{"at":"2024-01-15T10:30:00Z","in":"1m30s"}`},
+		{`parseTime("2024-13-01", "date")`, `error: parseTime: parsing time "2024-13-01": month out of range`},
+		{`inTimezone(now(), "Mars/Base")`, `error: unknown timezone: "Mars/Base"`},
+		{`duration("1x")`, `error: duration: time: unknown unit "x" in duration "1x"`},
+		{`now().week`, "error: unknown member of DATETIME: week"},
+		{`now() + 1`, "error: type mismatch: DATETIME + INTEGER"},
+		{`now() * now()`, "error: unknown operator: DATETIME * DATETIME"},
+		{`duration("1h") / 0`, "error: division by zero"},
+		{`now(1)`, "error: wrong number of arguments: 1 want: 0"},
+		{`formatTime("2024")`, "error: argument must be DATETIME, got: STRING"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluatedWithEnv(t, test.input, newEnv)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[abs(-5), abs(3), abs(-2.5)]`, "[5, 3, 2.5]"},
+		{`[min(3, 1, 2), max(3, 1.5), min([4, 2, 8]), max([2, 2.0])]`, "[1, 3, 2, 2]"},
+		{`[pow(2, 10), pow(2, -1), pow(4, 0.5), pow(-2, 3)]`, "[1024, 0.5, 2, -8]"},
+		{`[sqrt(16), sqrt(2) * sqrt(2) > 1.99]`, "[4, true]"},
+		{`[floor(2.7), floor(-2.5), ceil(2.1), ceil(5), round(2.5), round(-2.5), round(3.14159, 2)]`, "[2, -3, 3, 5, 3, -3, 3.14]"},
+		{`[clamp(15, 0, 10), clamp(-1, 0, 10), clamp(5, 0, 10), clamp(0.5, 0, 1)]`, "[10, 0, 5, 0.5]"},
+		{`[sum([1, 2, 3]), sum(1, 2.5), sum([]), avg([1, 2, 3, 4]), avg(5)]`, "[6, 3.5, 0, 2.5, 5]"},
+		{`r = random(); r >= 0 && r < 1`, "true"},
+		{`all(map(range(100), fn(x) { randomInt(1, 6) }), fn(x) { x >= 1 && x <= 6 })`, "true"},
+		{`randomInt(3, 3)`, "3"},
+		{`1 / 0`, "error: division by zero"},
+		{`5 % 0`, "error: modulo by zero"},
+		{`x = 1; x /= 0`, "error: division by zero"},
+		{`1.0 / 0`, "error: division by zero"},
+		{`1 / 0.0`, "error: division by zero"},
+		{`5.5 % 0`, "error: modulo by zero"},
+		{`5 % -0.0`, "error: modulo by zero"},
+		{`x = 1.5; x /= 0`, "error: division by zero"},
+		{`1.0 / 1e-300 > 1000`, "true"},
+		{`9223372036854775807 + 1`, "-9223372036854775808"},
+		{`abs(-9223372036854775807 - 1)`, "error: integer overflow: -(-9223372036854775808)"},
+		{`pow(10, 19)`, "error: integer overflow: pow"},
+		{`sum([9223372036854775807, 1])`, "error: integer overflow: 9223372036854775807 + 1"},
+		{`sqrt(-1)`, "error: square root of negative number: -1"},
+		{`floor(1e300)`, "error: value out of range of INTEGER: 1e+300"},
+		{`round(1.5, -1)`, "error: digits must be INTEGER from 0 to 15, got: -1"},
+		{`clamp(1, 10, 0)`, "error: low bound is greater than high bound: 10 > 0"},
+		{`min([])`, "error: empty array"},
+		{`max(1, "a")`, "error: second argument must be INTEGER, FLOAT or DECIMAL, got: STRING"},
+		{`sum([1, "a"])`, "error: element 1 must be INTEGER, FLOAT or DECIMAL, got: STRING"},
+		{`avg([])`, "error: average of empty array"},
+		{`abs("a")`, "error: argument must be INTEGER, FLOAT or DECIMAL, got: STRING"},
+		{`randomInt(5, 1)`, "error: min is greater than max: 5 > 1"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestSeededRandom(t *testing.T) {
+	newEnv := func() *object.Env {
+		env := object.NewEnv()
+		for name, b := range object.RandomBuiltins(rand.New(rand.NewSource(42))) {
+			env.Set(name, b)
+		}
+		return env
+	}
+
+	input := `[random(), randomInt(1, 1000), randomInt(1, 1000)]`
+	first := getEvaluatedWithEnv(t, input, newEnv)
+	second := getEvaluatedWithEnv(t, input, newEnv)
+
+	if first.ToString() != second.ToString() {
+		t.Errorf("results with the same seed differ: %s and %s", first.ToString(), second.ToString())
+	}
+}
+
+func TestCheckOverflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`9223372036854775807 + 1`, "error: integer overflow: 9223372036854775807 + 1"},
+		{`-9223372036854775807 - 2`, "error: integer overflow: -9223372036854775807 - 2"},
+		{`x = 4611686018427387904; x *= 2`, "error: integer overflow: 4611686018427387904 * 2"},
+		{`x = -9223372036854775807 - 1; -x`, "error: integer overflow: -(-9223372036854775808)"},
+		{`x = -9223372036854775807 - 1; x / -1`, "error: integer overflow: -9223372036854775808 / -1"},
+		{`9223372036854775806 + 1`, "9223372036854775807"},
+		{`-3 * 4 - 2`, "-14"},
+	}
+
+	limits := object.Limits{CheckOverflow: true}
+	for _, test := range tests {
+		program := parse(test.input)
+
+		ev := New(context.Background(), limits).Eval(program, object.NewEnv())
+
+		bc, err := compiler.Compile(program)
+		if err != nil {
+			t.Fatalf("compile error: %v in test: %q", err, test.input)
+		}
+		vmEv := vm.New(context.Background(), limits).Run(bc, object.NewEnv())
+
+		for _, res := range []object.Object{ev, vmEv} {
+			if res == nil || res.ToString() != test.expected {
+				t.Errorf("wrong result. got: %s expected: %s in test: %s", inspect(res), test.expected, test.input)
+			}
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`19.99d`, "19.99"},
+		{`[0.1d + 0.2d, 0.1d + 0.2d == 0.3d, 1.50d + 1, 2.5d * 1.5d, 10d - 0.01d, -1.5d]`, "[0.3, true, 2.50, 3.75, 9.99, -1.5]"},
+		{`[10.00d / 4, 1d / 3, 1d / 8, -7.5d % 2]`, "[2.50, 0.3333333333333333, 0.125, -1.5]"},
+		{`[1.5d < 2, 2.00d == 2, 1.10d > 1.1d, 1e2d]`, "[true, true, false, 100]"},
+		{`x = 0.1d; x += 0.2d; x`, "0.3"},
+		{`[decimal("19.99"), decimal(5), decimal(0.1), decimal(1.5d)]`, "[19.99, 5, 0.1, 1.5]"},
+		{`[divide(10d, 3, 2), divide(2, 3, 2, "down"), divide(-1d, 8, 2, "halfEven"), divide(1d, 8, 2), divide(1, 8, 2, "ceiling")]`, "[3.33, 0.66, -0.12, 0.13, 0.13]"},
+		{`[round(2.345d, 2), round(2.345d, 2, "halfEven"), round(2.5d), round(-2.5d, 0, "halfDown"), floor(-1.5d), ceil(1.2d)]`, "[2.35, 2.34, 3, -2, -2, 2]"},
+		{`[abs(-1.25d), min(1.5d, 1, 2d), max([1.5d, 2.25d]), sum([0.1d, 0.2d, 1]), avg(1d, 2d)]`, "[1.25, 1, 2.25, 1.3, 1.5]"},
+		{`[unique([1, 1.0d, 1.00d, 2]), contains([1.5d], 1.50d), {1.5d: "a"}[1.50d]]`, "[[1, 2], true, a]"},
+		{`jsonStringify({"price": 19.90d})`, `{"price":19.90}`},
+		{`1.5d + 1.5`, "error: type mismatch: DECIMAL + FLOAT"},
+		{`1.5d < 2.0`, "error: type mismatch: DECIMAL < FLOAT"},
+		// FLOAT is compared with DECIMAL by exact value
+		{`[1.5d == 1.5, 1.5 != 1.50d, 2d == 2.0, 0.1d == 0.1, 0.1d != 0.1]`, "[true, false, true, false, true]"},
+		{`[contains([1.5d], 1.5), unique([1.5, 1.50d, 2d, 2.0])]`, "[true, [1.5, 2]]"},
+		// division is rounded to 100 digits after point
+		{`decimal("1e-100") / 3d == 0d`, "true"},
+		{`decimal("2e-100") / 3d == decimal("1e-100")`, "true"},
+		{`1d / decimal("3e-100") > 3e99d`, "true"},
+		{`1d / 0`, "error: division by zero"},
+		{`1d % 0d`, "error: modulo by zero"},
+		{`1e1000d * 1e1000d`, "error: decimal is too large"},
+		{`decimal("abc")`, `error: decimal: invalid decimal: "abc"`},
+		{`decimal(true)`, "error: can't convert to DECIMAL: BOOLEAN"},
+		{`divide(1d, 3, 2, "nearest")`, "error: unknown rounding mode: nearest"},
+		{`divide(1.5, 1, 2)`, "error: first argument must be INTEGER or DECIMAL, got: FLOAT"},
+		{`divide(1d, 3, -1)`, "error: scale must be INTEGER from 0 to 100, got: -1"},
+		{`min(1d, 1.5)`, "error: type mismatch: FLOAT < DECIMAL"},
+		{`sqrt(2d)`, "error: argument must be INTEGER or FLOAT, got: DECIMAL"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestNull(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "Null"},
+		{"null == null", "true"},
+		{"null != null", "false"},
+		{"1 == null", "false"},
+		{`"a" != null`, "true"},
+		{`{"a": 1}["b"] == null`, "true"},
+		{"[1][5] ?? 7", "7"},
+		{"0 ?? 7", "0"},
+		{"false ?? true", "false"},
+		{"null ?? null", "Null"},
+		{"null ?? 1 + 2", "3"},
+		{"x = 1; null ?? x ?? 2", "1"},
+		{"1 ?? y", "1"},
+		{`user = {"profile": {"phone": "123"}}; user?.profile?["phone"] ?? "unknown"`, "123"},
+		{`user = {"profile": {}}; user?.profile?["phone"] ?? "unknown"`, "unknown"},
+		{`user = null; user?.profile?["phone"] ?? "unknown"`, "unknown"},
+		{`user = {}; user?.profile?.phone`, "Null"},
+		{`a = null; a?[b]`, "Null"},
+		// optional link skips the rest of chain
+		{`x = null; x?.a.b`, "Null"},
+		{`x = null; x?.a[0]`, "Null"},
+		{`x = null; x?[0].a[1:]`, "Null"},
+		{`x = null; x?.a.b ?? 5`, "5"},
+		{`x = {"a": {"b": [7]}}; x?.a.b[0]`, "7"},
+		{`x = {}; x?.a.b`, "error: member access not supported: NULL"},
+		{`x = {}; x.a?.b.c`, "Null"},
+		{"null + 1", "error: type mismatch: NULL + INTEGER"},
+		{"null < null", "error: unknown operator: NULL < NULL"},
+		{`null["a"]`, "error: index operator not supported: NULL"},
+		{`5?.a`, "error: member access not supported: INTEGER"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestMemberAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`m = {"e": {"l2a": 1}}; m.e.l2a`, "1"},
+		{`m = {"a": 1}; m.b`, "Null"},
+		{`m = {"if": 2}; m.if`, "2"},
+		{`m = {}; m.a = 5; m.a`, "5"},
+		{`m = {"e": {}}; m.e.l2a = 5; m["e"]["l2a"]`, "5"},
+		{`m = {"a": 1}; m.a = m.a + 1; m.a`, "2"},
+		{`m = {"a": {}}; x = m.a; x.b = 1; m.a.b`, "1"},
+		{`f = fn(m) { m.count = 10 }; m = {}; f(m); m.count`, "10"},
+		{`m = {"a": 1}; m.self = m; m.self.self.a`, "1"},
+		{`m = {}; m.self = m; m`, "[self: [...]]"},
+		{`m = {"e": {}}; m.e.parent = m; m`, "[e: [parent: [...]]]"},
+		{`m = {}; m.a.b = 1`, "error: member access not supported: NULL"},
+		{`a = [1]; a.b`, "error: member access not supported: ARRAY"},
+		{`a = 1; a.b = 2`, "error: member access not supported: INTEGER"},
+		{`m = {}; m.a = q`, "error: identifier not found: q"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestIndexAssign(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = [1, 2, 3]; a[1] = 5; a`, "[1, 5, 3]"},
+		{`m = {}; m["a"] = 1; m[2] = 3; m["a"] + m[2]`, "4"},
+		{`m = {"a": [1, 2]}; m.a[0] = 7; m["a"][0]`, "7"},
+		{`a = [{}]; a[0].x = 1; a[0]["x"]`, "1"},
+		{`a = [1]; f = fn(arr) { arr[0] = 2 }; f(a); a[0]`, "2"},
+		{`a = [1, 2]; a[0] = a; a`, "[[...], 2]"},
+		{`a = [1]; a[0] = a; a[0][0][0] == a`, "true"},
+		{`a = [1]; b = [a]; a[0] = b; a`, "[[[...]]]"},
+		{`m = {}; m["self"] = [m]; m`, "[self: [[...]]]"},
+		{`x = 5; x += 2; x`, "7"},
+		{`x = 5; x -= 2; x *= 4; x /= 3; x`, "4"},
+		{`x = 7; x %= 4; x`, "3"},
+		{`s = "a"; s += "b"; s`, "ab"},
+		{`x = 1; f = fn() { x += 1; x }; [f(), x]`, "[2, 2]"},
+		{`x = 1; f = fn() { let x = 5; x += 1; x }; [f(), x]`, "[6, 1]"},
+		{`a = [1, 2]; a[1] += 10; a`, "[1, 12]"},
+		{`m = {"n": 1}; m.n *= 5; m["n"] -= 1; m.n`, "4"},
+		{`m = {"n": 1}; i = 0; for (k in [1, 2, 3]) { m.n += k; i += 1 }; [m.n, i]`, "[7, 3]"},
+		{`a = [1]; a[1] = 2`, "error: index out of range: 1, length: 1"},
+		{`a = [1]; a[-1] = 2`, "error: index out of range: -1, length: 1"},
+		{`a = [1]; a["x"] = 2`, "error: array index must be INTEGER, got: STRING"},
+		{`m = {}; m[[1]] = 2`, "error: unusable as hash key: ARRAY"},
+		{`s = "abc"; s[0] = "x"`, "error: index assignment not supported: STRING"},
+		{`x += 1`, "error: identifier not found: x"},
+		{`x = 1; x += "a"`, "error: type mismatch: INTEGER + STRING"},
+		{`m = {}; m.a += 1`, "error: type mismatch: NULL + INTEGER"},
+		{`a = [1]; a[0] += q`, "error: identifier not found: q"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// assignment changes the nearest variable
+		{`x = 1; f = fn() { x = 2 }; f(); x`, "2"},
+		{`f = fn() { r = 5 }; r = 0; f(); r`, "5"},
+		{`f = fn() { t = 5; t }; f(); t`, "error: identifier not found: t"},
+		{`x = 1; f = fn() { let x = 2; g = fn() { x = 3 }; g(); x }; [f(), x]`, "[3, 1]"},
+		// counter
+		{`counter = fn() { let n = 0; fn() { n += 1 ; n } }; c = counter(); c(); c(); d = counter(); [c(), d()]`, "[3, 1]"},
+		// recursion: variables of calls are independent
+		{`a = 100; fib = fn(n) { if (n < 2) { return n }; let a = fib(n - 1); let b = fib(n - 2); a + b }; [fib(10), a]`, "[55, 100]"},
+		{`f = fn(n) { let g = fn(k) { if (k == 0) { return 0 }; k + g(k - 1) }; g(n) }; f(4)`, "10"},
+		// blocks
+		{`x = 1; if (true) { let x = 2; x += 1 }; x`, "1"},
+		{`if (true) { let y = 2 }; y`, "error: identifier not found: y"},
+		{`if (true) { z = 2 }; z`, "2"},
+		{`x = if (true) { let y = 2; y * 3 } else { 0 }; x`, "6"},
+		{`s = 0; i = 0; while (i < 3) { let d = i * 2; s += d; i += 1 }; s`, "6"},
+		{`for (v in [1]) { w = v }; [w, v ?? 0]`, "error: identifier not found: v"},
+		{`fs = []; for (v in [1, 2, 3]) { push(fs, fn() { v }) }; [fs[0](), fs[2]()]`, "[1, 3]"},
+		{`fs = []; for (v in [1, 2]) { let k = v * 10; push(fs, fn() { k }) }; [fs[0](), fs[1]()]`, "[10, 20]"},
+		{`let x = 1; let x = x + 1; x`, "2"},
+		{`const limit = 10; f = fn() { limit * 2 }; f()`, "20"},
+		{`const m = {"a": 1}; m.a = 2; m.a`, "2"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil {
+			t.Errorf("nil result in test: %s", test.input)
+			continue
+		}
+
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+// function changes global variable if it exists at runtime (variables of context), otherwise creates local variable
+func TestAssignGlobalFromFunction(t *testing.T) {
+	newEnv := func() *object.Env {
+		env := object.NewEnv()
+		env.Set("arr", &object.Array{Elements: []object.Object{
+			&object.Integer{Value: 1}, &object.Integer{Value: 2}, &object.Integer{Value: 3},
+		}})
+		env.Set("count", &object.Integer{Value: 1})
+		return env
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`g = fn() { arr = [9] }; g(); arr`, "[9]"},
+		{`g = fn() { count += 1 }; g(); g(); count`, "3"},
+		{`g = fn() { count = count + 1; count }; [g(), count]`, "[2, 2]"},
+		{`g = fn() { if (true) { arr = [] } }; g(); arr`, "[]"},
+		{`f = fn() { g = fn() { count = 10 }; g() }; f(); count`, "10"},
+		{`g = fn() { x = 2 }; x = 1; g(); x`, "2"},
+		{`g = fn() { let arr = [0]; arr = [5]; arr }; [g(), arr]`, "[[5], [1, 2, 3]]"},
+		{`g = fn() { tmp = 5; tmp }; g()`, "5"},
+		{`g = fn() { tmp = 5 }; g(); tmp`, "error: identifier not found: tmp"},
+		{`h = fn() { f = fn(n) { if (n == 0) { 0 } else { f(n - 1) } }; f(3) }; h()`, "0"},
+		// nested function uses variable which is assigned later in enclosing function
+		{`f = fn() { g = fn() { q }; q = 1; g() }; f()`, "1"},
+		{`f = fn() { inc = fn() { n = n + 1 }; n = 0; inc(); inc(); n }; f()`, "2"},
+		{`f = fn() { g = fn() { q = 2 }; q = 1; g(); q }; f()`, "2"},
+		{`f = fn() { g = fn() { q = 2 }; let q = 1; g(); q }; f()`, "2"},
+		{`f = fn() { g = fn() { q }; g() }; f()`, "error: identifier not found: q"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluatedWithEnv(t, test.input, newEnv)
+		if ev == nil || ev.ToString() != test.expected {
+			t.Errorf("wrong result: %s expected: %s in test: %s", inspect(ev), test.expected, test.input)
+		}
+	}
+}
+
+// assignment to constant is runtime error in both backends
+func TestConstAssign(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`const x = 1; x = 2`, "error: assignment to constant: x"},
+		{`const x = 1; x += 2`, "error: assignment to constant: x"},
+		{`const x = 1; f = fn() { x = 2 }; f()`, "error: assignment to constant: x"},
+		{`f = fn() { const x = 1; if (true) { x = 2 } }; f()`, "error: assignment to constant: x"},
+		{`const x = 1; x = fn() { 1 }`, "error: assignment to constant: x"},
+		{`const x = 1; x = q`, "error: identifier not found: q"},
+		{`const x = 1; if (false) { x = 2 }; x`, "1"},
+		{`const x = 1; f = fn() { x = 2 }; x`, "1"},
+		// constant declared after function
+		{`f = fn() { x = 2 }; const x = 1; f(); x`, "error: assignment to constant: x"},
+		{`f = fn() { g = fn() { q = 2 }; const q = 1; g(); q }; f()`, "error: assignment to constant: q"},
+		{`f = fn() { x = 2 }; f(); const x = 1; x`, "1"},
+		{`x = 2; const x = 1; x`, "1"},
+		{`let x = 1; const x = 2; let x = 3; x = 4; x`, "4"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluated(t, test.input)
+		if ev == nil || ev.ToString() != test.expected {
+			t.Errorf("wrong result: %s expected: %s in test: %s", inspect(ev), test.expected, test.input)
+		}
+	}
+}
+
+func TestHostFunctions(t *testing.T) {
+	type ctxKey struct{}
+	type item struct {
+		Name  string   `bql:"name"`
+		Price *big.Rat `json:"price,omitempty"`
+		Tags  []string
+		Skip  bool `bql:"-"`
+	}
+
+	funcs := map[string]any{
+		"add":   func(a, b int) int { return a + b },
+		"half":  func(x float64) float64 { return x / 2 },
+		"greet": func(name string) (string, error) { return "hi " + name, nil },
+		"fail":  func() error { return errors.New("boom") },
+		"sum": func(xs ...int64) int64 {
+			var s int64
+			for _, x := range xs {
+				s += x
+			}
+			return s
+		},
+		"keys":    func(m map[string]any) []string { return []string{"a"} },
+		"user":    func(ctx context.Context) string { return ctx.Value(ctxKey{}).(string) },
+		"small":   func(x int8) int8 { return x },
+		"nothing": func() {},
+		"makeItem": func() item {
+			return item{Name: "tea", Price: big.NewRat(199, 100), Tags: []string{"hot"}}
+		},
+		"itemName": func(i item) string { return i.Name + " " + i.Price.FloatString(2) },
+		"later":    func(t time.Time, d time.Duration) time.Time { return t.Add(d) },
+		"explode":  func(s string) string { panic("bad " + s) },
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "bob")
+	newEnv := func() *object.Env {
+		env := object.NewEnv()
+		for name, fn := range funcs {
+			hf, err := object.NewHostFunction(name, fn)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			env.Set(name, hf.Builtin(ctx))
+		}
+		return env
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"add(1, 2)", "3"},
+		{"half(3)", "1.5"},
+		{`greet("bob")`, "hi bob"},
+		{"sum()", "0"},
+		{"sum(1, 2, 3)", "6"},
+		{`keys({"a": [1]})`, "[a]"},
+		{`keys({"a": 1, 1: 2})`, "error: argument 1 of keys: key 1 must be STRING, got: INTEGER"},
+		{`keys({true: 1})`, "error: argument 1 of keys: key true must be STRING, got: BOOLEAN"},
+		{`explode("input")`, "error: function explode: panic: bad input"},
+		{"user()", "bob"},
+		{"nothing()", "Null"},
+		{"fail()", "error: fail: boom"},
+		{"add(1)", "error: wrong number of arguments: 1 want: 2"},
+		{`add(1, "2")`, "error: argument 2 of add: must be INTEGER, got: STRING"},
+		{"small(1000)", "error: argument 1 of small: value out of range of int8: 1000"},
+		{"makeItem()", "[name: tea, price: 1.99, Tags: [hot]]"},
+		{`itemName({"name": "tea", "price": 1.99d, "other": 1})`, "tea 1.99"},
+		{`later(parseTime("2024-01-01", "date"), duration("1h"))`, "2024-01-01T01:00:00Z"},
+		{`itemName({"name": 1})`, "error: argument 1 of itemName: field name: must be STRING, got: INTEGER"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluatedWithEnv(t, test.input, newEnv)
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+
+	for _, fn := range []any{1, func(ch chan int) {}, func() (int, int) { return 0, 0 }} {
+		if _, err := object.NewHostFunction("f", fn); err == nil {
+			t.Errorf("expected error for unsupported function: %T", fn)
+		}
+	}
+}
+
+func TestGoConversion(t *testing.T) {
+	type inner struct {
+		ID int `json:"id"`
+	}
+	type value struct {
+		inner
+		Count  uint8
+		Ratio  float32
+		Amount *big.Rat
+		Tags   map[string][]any
+		Nums   map[int]string
+		Next   *value
+		At     time.Time
+		hidden int
+	}
+
+	v := value{
+		inner:  inner{ID: 7},
+		Count:  3,
+		Ratio:  0.5,
+		Amount: big.NewRat(5, 2),
+		Tags:   map[string][]any{"b": {1, nil}, "a": {json.Number("2.5")}},
+		Nums:   map[int]string{2: "two", 1: "one"},
+		At:     time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	obj, err := object.FromGo(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "[id: 7, Count: 3, Ratio: 0.5, Amount: 2.5, Tags: [a: [2.5], b: [1, Null]], Nums: [1: one, 2: two], Next: Null, At: 2024-01-15T00:00:00Z]"
+	if obj.ToString() != expected {
+		t.Errorf("wrong result. got: %s expected: %s", obj.ToString(), expected)
+	}
+
+	for _, v := range []any{nil, int(1), []any{int64(1), "a", true, nil}, map[string]any{"x": []any{2.5}}} {
+		obj, err := object.FromGo(v)
+		if err != nil {
+			t.Fatalf("unexpected error for %#v: %v", v, err)
+		}
+
+		back, err := object.ToGo(obj)
+		if err != nil {
+			t.Fatalf("unexpected error for %#v: %v", v, err)
+		}
+
+		want := v
+		if i, ok := v.(int); ok {
+			want = int64(i)
+		}
+		if !reflect.DeepEqual(back, want) {
+			t.Errorf("wrong result. got: %#v expected: %#v", back, want)
+		}
+	}
+
+	if _, err := object.FromGo(make(chan int)); err == nil {
+		t.Errorf("expected error for unsupported type")
+	}
+
+	if _, err := object.FromGo(uint64(1 << 63)); err == nil {
+		t.Errorf("expected error for uint64 out of range")
+	}
+
+	if _, err := object.ToGo(&object.Function{}); err == nil {
+		t.Errorf("expected error for function")
+	}
+
+	// keys of different types are equal after ToString
+	for input, expected := range map[string]string{
+		`{1: "a", "1": "b"}`:          "duplicate key: 1",
+		`{"x": {true: 1, "true": 2}}`: "duplicate key: true",
+	} {
+		if _, err := object.ToGo(getEvaluated(t, input)); err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got: %v in test: %s", expected, err, input)
+		}
+	}
+}
+
+func TestCyclicValues(t *testing.T) {
+	show, err := object.NewHostFunction("show", func(v any) string { return fmt.Sprint(v) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	newEnv := func() *object.Env {
+		env := object.NewEnv()
+		env.Set("show", show.Builtin(context.Background()))
+		return env
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`m = {"a": 1}; m.self = m; m`, "[a: 1, self: [...]]"},
+		{`a = [1]; a[0] = a; a`, "[[...]]"},
+		{`a = [1]; b = [a, a]; b`, "[[1], [1]]"},
+		{"a = [1]; m = {\"a\": a}; a[0] = m; `${m}`", "[a: [[...]]]"},
+		{`m = {}; m.self = m; jsonStringify(m)`, "error: jsonStringify: cyclic value"},
+		{`a = [1]; a[0] = [a]; jsonStringify(a)`, "error: jsonStringify: cyclic value"},
+		{`m = {}; m.self = m; show(m)`, "error: argument 1 of show: cyclic value"},
+		{`a = [1]; a[0] = a; show(a)`, "error: argument 1 of show: cyclic value"},
+	}
+
+	for _, test := range tests {
+		ev := getEvaluatedWithEnv(t, test.input, newEnv)
+		if ev.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s in test: %s", ev.ToString(), test.expected, test.input)
+		}
+	}
+
+	for _, input := range []string{`m = {}; m.self = m; m`, `a = [1]; a[0] = [a]; a`} {
+		if _, err := object.ToGo(getEvaluated(t, input)); err == nil || err.Error() != "cyclic value" {
+			t.Errorf("expected cyclic value error, got: %v in test: %s", err, input)
+		}
+	}
+
+	type node struct {
+		Next *node
+	}
+	n := &node{}
+	n.Next = n
+	s := []any{nil}
+	s[0] = s
+	m := map[string]any{}
+	m["self"] = m
+
+	for _, v := range []any{n, s, m} {
+		if _, err := object.FromGo(v); err == nil {
+			t.Errorf("expected error for cyclic value: %T", v)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		limits   object.Limits
+		expected string // "" - no limit error
+	}{
+		{"f = fn() { f() }; f()", object.Limits{MaxCallDepth: 100}, object.CALL_DEPTH_LIMIT},
+		{"f = fn(n) { if (n == 0) { return 0 }; f(n - 1) }; f(50)", object.Limits{MaxCallDepth: 100}, ""},
+		{"f = fn(n) { if (n == 0) { return 0 }; f(n - 1) }; f(150)", object.Limits{MaxCallDepth: 100}, object.CALL_DEPTH_LIMIT},
+		// zero MaxCallDepth is default limit, negative disables it
+		{"f = fn() { f() }; f()", object.Limits{}, object.CALL_DEPTH_LIMIT},
+		{"f = fn() { f() }; f()", object.Limits{MaxSteps: 100000}, object.CALL_DEPTH_LIMIT},
+		{"f = fn(n) { if (n == 0) { return 0 }; f(n - 1) }; f(1500)", object.Limits{}, object.CALL_DEPTH_LIMIT},
+		{"f = fn(n) { if (n == 0) { return 0 }; f(n - 1) }; f(1500)", object.Limits{MaxCallDepth: -1}, ""},
+		{"while (true) { }", object.Limits{MaxSteps: 1000}, object.STEPS_LIMIT},
+		{"i = 0; while (i < 10) { i = i + 1 }", object.Limits{MaxSteps: 1000}, ""},
+		{`s = "a"; while (true) { s = s + s }`, object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+		{`a = []; while (true) { push(a, 1) }`, object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+		{`[1, 2, 3, 4]`, object.Limits{MaxAllocSize: 3}, object.ALLOC_SIZE_LIMIT},
+		{`{1: 1, 2: 2}`, object.Limits{MaxAllocSize: 1}, object.ALLOC_SIZE_LIMIT},
+		{`m = {}; m.a = 1; m.b = 2`, object.Limits{MaxAllocSize: 1}, object.ALLOC_SIZE_LIMIT},
+		{`"ab" + "cd"`, object.Limits{MaxAllocSize: 4}, ""},
+		{`s = "abc"; s += s`, object.Limits{MaxAllocSize: 5}, object.ALLOC_SIZE_LIMIT},
+		// projected size is checked before builtin builds value, otherwise builtin reports too large result
+		{`repeat("ab", 2)`, object.Limits{MaxAllocSize: 4}, ""},
+		{`repeat("ab", 100000000)`, object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+		{`range(1000000000)`, object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+		{`range(0, 1000000000, 1000000)`, object.Limits{MaxAllocSize: 1000}, ""},
+		{`padLeft("a", 100000000, "ж")`, object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+		{`padRight("ab", 4, "ж")`, object.Limits{MaxAllocSize: 5}, object.ALLOC_SIZE_LIMIT},
+		{`padRight("ab", 4)`, object.Limits{MaxAllocSize: 5}, ""},
+		{`replace("aaaa", "a", "bbb")`, object.Limits{MaxAllocSize: 12}, ""},
+		{`replace("aaaa", "a", "bbb")`, object.Limits{MaxAllocSize: 11}, object.ALLOC_SIZE_LIMIT},
+		{`replace("abc", "", "--")`, object.Limits{MaxAllocSize: 10}, object.ALLOC_SIZE_LIMIT},
+		{`replaceRegex("a1b22", "[0-9]+", "<$0>")`, object.Limits{MaxAllocSize: 9}, ""},
+		{`replaceRegex("a1b22", "[0-9]+", "<$0>")`, object.Limits{MaxAllocSize: 8}, object.ALLOC_SIZE_LIMIT},
+		{`join(["ab", 12, "c"], ", ")`, object.Limits{MaxAllocSize: 9}, ""},
+		{`join(["ab", 12, "c"], ", ")`, object.Limits{MaxAllocSize: 8}, object.ALLOC_SIZE_LIMIT},
+		{`format("{0}-{0}-{}", "abc")`, object.Limits{MaxAllocSize: 11}, ""},
+		{`format("{0}-{0}-{}", "abc")`, object.Limits{MaxAllocSize: 10}, object.ALLOC_SIZE_LIMIT},
+		{`concat([1, 2], [3], [4, 5])`, object.Limits{MaxAllocSize: 4}, object.ALLOC_SIZE_LIMIT},
+		{`jsonStringify({"a": [1, "b"]})`, object.Limits{MaxAllocSize: 13}, ""},
+		{`jsonStringify({"a": [1, "b"]})`, object.Limits{MaxAllocSize: 12}, object.ALLOC_SIZE_LIMIT},
+		{`jsonStringify([1, 2], 2)`, object.Limits{MaxAllocSize: 12}, ""},
+		{`jsonStringify([1, 2], 2)`, object.Limits{MaxAllocSize: 11}, object.ALLOC_SIZE_LIMIT},
+		{"s = \"abcd\"; `${s}:${s}`", object.Limits{MaxAllocSize: 9}, ""},
+		{"s = \"abcd\"; `${s}:${s}`", object.Limits{MaxAllocSize: 8}, object.ALLOC_SIZE_LIMIT},
+		{"s = repeat(\"a\", 600); `${[s, s]}`", object.Limits{MaxAllocSize: 1000}, object.ALLOC_SIZE_LIMIT},
+	}
+
+	for _, test := range tests {
+		program := parse(test.input)
+
+		for name, run := range backends {
+			ev, err := run(context.Background(), program, test.limits)
+			if test.expected == "" {
+				if err != nil {
+					t.Errorf("%s: unexpected limit error: %v in test: %s", name, err, test.input)
+				}
+				continue
+			}
+
+			var limitErr *object.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Errorf("%s: error is not LimitError: %v in test: %s", name, err, test.input)
+				continue
+			}
+
+			if limitErr.Limit != test.expected {
+				t.Errorf("%s: wrong limit. got: %s expected: %s", name, limitErr.Limit, test.expected)
+			}
+
+			if !isError(ev) {
+				t.Errorf("%s: non error object returned: %T - %+v", name, ev, ev)
+			}
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	program := parse("while (true) { }")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, run := range backends {
+		_, err := run(ctx, program, object.Limits{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: error is not context.Canceled: %v", name, err)
+		}
+	}
+}
+
+type backend func(ctx context.Context, program *ast.Program, limits object.Limits) (object.Object, error)
+
+var backends = map[string]backend{
+	"evaluator": func(ctx context.Context, program *ast.Program, limits object.Limits) (object.Object, error) {
+		e := New(ctx, limits)
+		ev := e.Eval(program, object.NewEnv())
+		return ev, e.Err()
+	},
+	"vm": func(ctx context.Context, program *ast.Program, limits object.Limits) (object.Object, error) {
+		bc, err := compiler.Compile(program)
+		if err != nil {
+			return nil, err
+		}
+
+		m := vm.New(ctx, limits)
+		ev := m.Run(bc, object.NewEnv())
+		return ev, m.Err()
+	},
+}
+
+func parse(input string) *ast.Program {
 	l := lexer.New(input)
 	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnv()
-	return Eval(program, env)
+	return p.ParseProgram()
+}
+
+// evaluates input by all backends, results must be equal
+func getEvaluated(t *testing.T, input string) object.Object {
+	t.Helper()
+	return getEvaluatedWithEnv(t, input, object.NewEnv)
+}
+
+// newEnv is called for each backend
+func getEvaluatedWithEnv(t *testing.T, input string, newEnv func() *object.Env) object.Object {
+	t.Helper()
+
+	program := parse(input)
+
+	ev := Eval(program, newEnv())
+
+	bc, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatalf("compile error: %v in test: %q", err, input)
+	}
+	vmEv := vm.New(context.Background(), object.Limits{}).Run(bc, newEnv())
+
+	if !equalObjects(ev, vmEv) {
+		t.Errorf("results of backends differ. evaluator: %s vm: %s in test: %q", inspect(ev), inspect(vmEv), input)
+	}
+
+	return ev
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+
+	if err, ok := obj.(*object.Error); ok {
+		return fmt.Sprintf("%s (%d:%d)", err.ToString(), err.Pos.Line, err.Pos.Offset)
+	}
+
+	return obj.ToString()
+}
+
+func equalObjects(a, b object.Object) bool {
+	return equalValues(a, b, map[[2]object.Object]bool{})
+}
+
+// compared pairs of containers are remembered, values can be cyclic
+func equalValues(a, b object.Object, seen map[[2]object.Object]bool) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *object.Error:
+		b := b.(*object.Error)
+		return a.Message == b.Message && a.Pos == b.Pos
+	case *object.Array:
+		b := b.(*object.Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+
+		if seen[[2]object.Object{a, b}] {
+			return true
+		}
+		seen[[2]object.Object{a, b}] = true
+
+		for id := range a.Elements {
+			if !equalValues(a.Elements[id], b.Elements[id], seen) {
+				return false
+			}
+		}
+
+		return true
+	case *object.HashMap:
+		b := b.(*object.HashMap)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+
+		if seen[[2]object.Object{a, b}] {
+			return true
+		}
+		seen[[2]object.Object{a, b}] = true
+
+		for key, pair := range a.Pairs {
+			other, ok := b.Pairs[key]
+			if !ok || !equalValues(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return a.ToString() == b.ToString()
+	}
 }
 
 func testInteger(t *testing.T, obj object.Object, expected int64) {
@@ -484,6 +1780,18 @@ func testInteger(t *testing.T, obj object.Object, expected int64) {
 	}
 }
 
+func testFloat(t *testing.T, obj object.Object, expected float64) {
+	res, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("obj not Float got:%+v", obj)
+		return
+	}
+
+	if res.Value != expected {
+		t.Errorf("obj wrong value. got: %g expected: %g", res.Value, expected)
+	}
+}
+
 func testBoolean(t *testing.T, obj object.Object, expected bool, in string) {
 	res, ok := obj.(*object.Boolean)
 	if !ok {
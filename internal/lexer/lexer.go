@@ -1,14 +1,38 @@
 package lexer
 
-import "github.com/botscubes/bql/internal/token"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/botscubes/bql/internal/token"
+)
 
 type Lexer struct {
 	input   string
-	ch      byte // current char
-	pos     int  // current position (on current char)
-	readPos int  // position after current char
-	nlsemi  bool // if "true" '\n' translate to ';'
-	loPos   token.Pos
+	ch      byte      // current char
+	pos     int       // current position (on current char)
+	readPos int       // position after current char
+	nlsemi  bool      // if "true" '\n' translate to ';'
+	loPos   token.Pos // line and column of current char
+	errors  []Error
+
+	templates []template // templates whose expressions (${...}) are being read, the innermost is the last
+}
+
+type template struct {
+	start  token.Pos // position of opening backtick
+	braces int       // number of open braces in expression
+}
+
+type Error struct {
+	Pos     token.Pos
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("pos: %d:%d: %s", e.Pos.Line, e.Pos.Offset, e.Message)
 }
 
 func New(input string) *Lexer {
@@ -25,9 +49,24 @@ func New(input string) *Lexer {
 	return l
 }
 
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+func (l *Lexer) newError(pos token.Pos, e string) {
+	l.errors = append(l.errors, Error{Pos: pos, Message: e})
+}
+
+// position of current char. After NextToken - position of char after returned token
+func (l *Lexer) Pos() token.Pos {
+	return l.loPos
+}
+
+// returns token and position of its first char
 func (l *Lexer) NextToken() (token.Token, token.Pos) {
 	l.skipWhitespace()
 
+	pos := l.loPos
 	nlsemi := false
 
 	var tok token.Token
@@ -43,13 +82,33 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.PLUS_ASSIGN, Literal: "+="}
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.MINUS_ASSIGN, Literal: "-="}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '*':
-		tok = newToken(token.STAR, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.STAR_ASSIGN, Literal: "*="}
+		} else {
+			tok = newToken(token.STAR, l.ch)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.SLASH_ASSIGN, Literal: "/="}
+		} else {
+			tok = newToken(token.SLASH, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			l.readChar()
@@ -60,7 +119,12 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 		}
 
 	case '%':
-		tok = newToken(token.PERCENT, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.PERCENT_ASSIGN, Literal: "%="}
+		} else {
+			tok = newToken(token.PERCENT, l.ch)
+		}
 	case '<':
 		if l.peekChar() == '=' {
 			l.readChar()
@@ -83,14 +147,33 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case '(':
 		tok = newToken(token.LPAR, l.ch)
 	case ')':
 		nlsemi = true
 		tok = newToken(token.RPAR, l.ch)
 	case '{':
+		if n := len(l.templates); n != 0 {
+			l.templates[n-1].braces++
+		}
 		tok = newToken(token.LBRACE, l.ch)
+	case '`':
+		tok = l.readTemplate(pos, false)
+		nlsemi = tok.Type == token.TEMPLATE
 	case '}':
+		// end of expression of template
+		if n := len(l.templates); n != 0 && l.templates[n-1].braces == 0 {
+			t := l.templates[n-1]
+			l.templates = l.templates[:n-1]
+			tok = l.readTemplate(t.start, true)
+			nlsemi = tok.Type == token.TEMPLATE_TAIL
+			break
+		} else if n != 0 {
+			l.templates[n-1].braces--
+		}
+
 		nlsemi = true
 		tok = newToken(token.RBRACE, l.ch)
 	case '[':
@@ -100,7 +183,7 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 		tok = newToken(token.RBRACKET, l.ch)
 	case '"':
 		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok.Literal = l.readString(pos)
 		nlsemi = true
 	case '&':
 		if l.peekChar() == '&' {
@@ -118,6 +201,20 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			l.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			l.readChar()
+			tok = token.Token{Type: token.QUESTION_DOT, Literal: "?."}
+		case '[':
+			l.readChar()
+			tok = token.Token{Type: token.QUESTION_LBRACKET, Literal: "?["}
+		default:
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case 0:
 		tok = token.Token{Type: token.EOF, Literal: ""}
 	default:
@@ -125,27 +222,33 @@ func (l *Lexer) NextToken() (token.Token, token.Pos) {
 			tok.Literal = l.readIdent()
 			tok.Type = token.LookupIdent(tok.Literal)
 
-			if tok.Type == token.IDENT || tok.Type == token.TRUE || tok.Type == token.FALSE {
+			switch tok.Type {
+			case token.IDENT, token.TRUE, token.FALSE, token.NULL, token.BREAK, token.CONTINUE:
 				l.nlsemi = true
 			}
-			return tok, l.loPos
+			return tok, pos
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
 			l.nlsemi = true
-			return tok, l.loPos
+			return tok, pos
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.readIllegalChar()}
 		}
 	}
 
+	if tok.Type == token.ILLEGAL {
+		l.newError(pos, fmt.Sprintf("illegal character: %q", tok.Literal))
+	}
+
 	l.nlsemi = nlsemi
 
 	l.readChar()
-	return tok, l.loPos
+	return tok, pos
 }
 
 func (l *Lexer) readChar() {
+	prev := l.ch
+
 	if l.readPos >= len(l.input) {
 		l.ch = 0 // EOF
 	} else {
@@ -153,8 +256,15 @@ func (l *Lexer) readChar() {
 	}
 
 	l.pos = l.readPos
-	l.loPos.Offset += 1
 	l.readPos += 1
+
+	if prev == '\n' {
+		l.loPos.Line += 1
+		l.loPos.Offset = 0
+	} else if l.ch&0xC0 != 0x80 {
+		// column is counted in characters, UTF-8 continuation bytes are skipped
+		l.loPos.Offset += 1
+	}
 }
 
 func (l *Lexer) peekChar() byte {
@@ -166,12 +276,9 @@ func (l *Lexer) peekChar() byte {
 }
 
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\n' && !l.nlsemi || l.ch == '\t' || l.ch == '\r' {
+	// expression of template can be written on several lines
+	for l.ch == ' ' || l.ch == '\n' && (!l.nlsemi || len(l.templates) != 0) || l.ch == '\t' || l.ch == '\r' {
 		l.readChar()
-
-		if l.ch == '\n' {
-			l.onNewLine()
-		}
 	}
 }
 
@@ -191,30 +298,170 @@ func (l *Lexer) readIdent() string {
 	return l.input[position:l.pos]
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) peekCharN(n int) byte {
+	if l.pos+n >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos+n]
+}
+
+// reads integer (123) or float (1.5, 2e10, 1.5e-3) number
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.pos
+	tokType := token.INT
+
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if isDigit(next) || (next == '+' || next == '-') && isDigit(l.peekCharN(2)) {
+			tokType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+
+	// suffix d makes decimal: 19.99d
+	if l.ch == 'd' && !isLetter(l.peekChar()) && !isDigit(l.peekChar()) {
+		l.readChar()
+		return token.DECIMAL, l.input[position:l.pos]
+	}
+
+	return tokType, l.input[position:l.pos]
+}
+
+func (l *Lexer) readDigits() {
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.pos]
 }
 
-func (l *Lexer) readString() string {
-	position := l.pos + 1
+// reads string literal, current char is opening quote, start - position of quote
+func (l *Lexer) readString(start token.Pos) string {
+	var out strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		switch l.ch {
+		case '"':
+			return out.String()
+		case 0:
+			l.newError(start, "unterminated string")
+			return out.String()
+		case '\\':
+			l.readEscape(&out, '"')
+		default:
+			out.WriteByte(l.ch)
 		}
 	}
-	return l.input[position:l.pos]
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
+// reads text of template until ${ or closing backtick, current char is opening backtick
+// or brace which closes expression (cont), start - position of opening backtick
+func (l *Lexer) readTemplate(start token.Pos, cont bool) token.Token {
+	end, head := token.TokenType(token.TEMPLATE), token.TokenType(token.TEMPLATE_HEAD)
+	if cont {
+		end, head = token.TEMPLATE_TAIL, token.TEMPLATE_MIDDLE
+	}
+
+	var out strings.Builder
+	for {
+		l.readChar()
+		switch {
+		case l.ch == '`':
+			return token.Token{Type: end, Literal: out.String()}
+		case l.ch == 0:
+			l.newError(start, "unterminated template")
+			return token.Token{Type: end, Literal: out.String()}
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			l.templates = append(l.templates, template{start: start})
+			return token.Token{Type: head, Literal: out.String()}
+		case l.ch == '\\' && l.peekChar() == '$':
+			l.readChar()
+			out.WriteByte('$')
+		case l.ch == '\\':
+			l.readEscape(&out, '`')
+		default:
+			out.WriteByte(l.ch)
+		}
+	}
+}
+
+// reads escape sequence, current char is backslash. Sequences: \n \t \r \\ \u{hex} and escaped quote
+func (l *Lexer) readEscape(out *strings.Builder, quote byte) {
+	pos := l.loPos
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '\\', quote:
+		out.WriteByte(l.ch)
+	case 'u':
+		l.readUnicodeEscape(out, pos)
+	case 0:
+		// unterminated string is reported by caller
+	default:
+		l.newError(pos, fmt.Sprintf("unknown escape sequence: \\%s", l.readIllegalChar()))
+	}
+}
+
+// reads \u{hex}, current char is 'u'
+func (l *Lexer) readUnicodeEscape(out *strings.Builder, pos token.Pos) {
+	if l.peekChar() != '{' {
+		l.newError(pos, "invalid unicode escape: expected \\u{hex}")
+		return
+	}
+	l.readChar()
+
+	start := l.readPos
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	hex := l.input[start:l.readPos]
+
+	if l.peekChar() != '}' {
+		l.newError(pos, fmt.Sprintf("invalid unicode escape: \\u{%s", hex))
+		return
+	}
+	l.readChar()
+
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) > 6 || !utf8.ValidRune(rune(code)) {
+		l.newError(pos, fmt.Sprintf("invalid unicode escape: \\u{%s}", hex))
+		return
+	}
+
+	out.WriteRune(rune(code))
+}
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
-func (l *Lexer) onNewLine() {
-	l.loPos.Line += 1
-	l.loPos.Offset = -1
+// reads all bytes of (possibly multibyte) char, stops on the last byte
+func (l *Lexer) readIllegalChar() string {
+	_, size := utf8.DecodeRuneInString(l.input[l.pos:])
+	position := l.pos
+	for i := 1; i < size; i++ {
+		l.readChar()
+	}
+	return l.input[position : l.pos+1]
+}
+
+func newToken(tokenType token.TokenType, ch byte) token.Token {
+	return token.Token{Type: tokenType, Literal: string(ch)}
 }
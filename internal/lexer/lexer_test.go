@@ -220,3 +220,342 @@ _99;
 		}
 	}
 }
+
+func TestNextTokenNumbers(t *testing.T) {
+	input := `1 1.5 0.25 1e3 2E-4 3.5e+2 7. 1e a[0] 19.99d 5d 1e-2d 2dx`
+
+	tests := []ExpectedToken{
+		{token.INT, "1"},
+		{token.FLOAT, "1.5"},
+		{token.FLOAT, "0.25"},
+		{token.FLOAT, "1e3"},
+		{token.FLOAT, "2E-4"},
+		{token.FLOAT, "3.5e+2"},
+		{token.INT, "7"},
+		{token.DOT, "."},
+		{token.INT, "1"},
+		{token.IDENT, "e"},
+		{token.IDENT, "a"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.DECIMAL, "19.99d"},
+		{token.DECIMAL, "5d"},
+		{token.DECIMAL, "1e-2d"},
+		{token.INT, "2"},
+		{token.IDENT, "dx"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, _ := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong: expected=%q, got=%q",
+				i, test.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenLoops(t *testing.T) {
+	input := `while (true) {
+	break
+}
+for (k, v in m) {
+	continue
+}
+`
+
+	tests := []ExpectedToken{
+		{token.WHILE, "while"},
+		{token.LPAR, "("},
+		{token.TRUE, "true"},
+		{token.RPAR, ")"},
+		{token.LBRACE, "{"},
+		{token.BREAK, "break"},
+		{token.SEMICOLON, "\n"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, "\n"},
+		{token.FOR, "for"},
+		{token.LPAR, "("},
+		{token.IDENT, "k"},
+		{token.COMMA, ","},
+		{token.IDENT, "v"},
+		{token.IN, "in"},
+		{token.IDENT, "m"},
+		{token.RPAR, ")"},
+		{token.LBRACE, "{"},
+		{token.CONTINUE, "continue"},
+		{token.SEMICOLON, "\n"},
+		{token.RBRACE, "}"},
+		{token.SEMICOLON, "\n"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, _ := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong: expected=%q, got=%q",
+				i, test.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenNull(t *testing.T) {
+	input := `x = null
+user?.profile?["phone"] ?? "unknown"`
+
+	tests := []ExpectedToken{
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.NULL, "null"},
+		{token.SEMICOLON, "\n"},
+		{token.IDENT, "user"},
+		{token.QUESTION_DOT, "?."},
+		{token.IDENT, "profile"},
+		{token.QUESTION_LBRACKET, "?["},
+		{token.STRING, "phone"},
+		{token.RBRACKET, "]"},
+		{token.NULLISH, "??"},
+		{token.STRING, "unknown"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, _ := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong: expected=%q, got=%q",
+				i, test.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenPosition(t *testing.T) {
+	input := `x = 2
+y = "абв" + 33
+	z $ &`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Pos
+	}{
+		{token.IDENT, token.Pos{Line: 1, Offset: 0}},
+		{token.ASSIGN, token.Pos{Line: 1, Offset: 2}},
+		{token.INT, token.Pos{Line: 1, Offset: 4}},
+		{token.SEMICOLON, token.Pos{Line: 1, Offset: 5}},
+		{token.IDENT, token.Pos{Line: 2, Offset: 0}},
+		{token.ASSIGN, token.Pos{Line: 2, Offset: 2}},
+		{token.STRING, token.Pos{Line: 2, Offset: 4}},
+		{token.PLUS, token.Pos{Line: 2, Offset: 10}},
+		{token.INT, token.Pos{Line: 2, Offset: 12}},
+		{token.SEMICOLON, token.Pos{Line: 2, Offset: 14}},
+		{token.IDENT, token.Pos{Line: 3, Offset: 1}},
+		{token.ILLEGAL, token.Pos{Line: 3, Offset: 3}},
+		{token.ILLEGAL, token.Pos{Line: 3, Offset: 5}},
+		{token.EOF, token.Pos{Line: 3, Offset: 6}},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, pos := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if pos != test.expectedPos {
+			t.Fatalf("tests[%d] - pos wrong: expected=%+v, got=%+v",
+				i, test.expectedPos, pos)
+		}
+	}
+
+	if len(l.Errors()) != 2 {
+		t.Fatalf("wrong number of errors. expected: 2 got:%d", len(l.Errors()))
+	}
+
+	expected := `pos: 3:3: illegal character: "$"`
+	if l.Errors()[0].Error() != expected {
+		t.Errorf("wrong error. expected: %q got:%q", expected, l.Errors()[0].Error())
+	}
+}
+
+func TestNextTokenCompoundAssign(t *testing.T) {
+	input := `x += 1; x -= 2; x *= 3; x /= 4; x %= 5; a[0]=-1`
+
+	tests := []ExpectedToken{
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.STAR_ASSIGN, "*="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.ASSIGN, "="},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, _ := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong: expected=%q, got=%q",
+				i, test.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`"a\tb\r"`, "a\tb\r"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"back\\slash"`, `back\slash`},
+		{`"\u{41}\u{1F600}"`, "A\U0001F600"},
+		{`"привет"`, "привет"},
+		{"\"two\nlines\"", "two\nlines"},
+	}
+
+	for _, test := range tests {
+		l := New(test.input)
+		tok, _ := l.NextToken()
+
+		if tok.Type != token.STRING || tok.Literal != test.expected {
+			t.Errorf("wrong token for %s. expected: %q got: %s %q", test.input, test.expected, tok.Type, tok.Literal)
+		}
+
+		if len(l.Errors()) != 0 {
+			t.Errorf("unexpected errors for %s: %v", test.input, l.Errors())
+		}
+	}
+}
+
+func TestNextTokenStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = "abc`, "pos: 1:4: unterminated string"},
+		{`x = "abc\"`, "pos: 1:4: unterminated string"},
+		{`"a\qb"`, `pos: 1:2: unknown escape sequence: \q`},
+		{`"\ё"`, `pos: 1:1: unknown escape sequence: \ё`},
+		{`"\u41"`, `pos: 1:1: invalid unicode escape: expected \u{hex}`},
+		{`"\u{41"`, `pos: 1:1: invalid unicode escape: \u{41`},
+		{`"\u{}"`, `pos: 1:1: invalid unicode escape: \u{}`},
+		{`"\u{110000}"`, `pos: 1:1: invalid unicode escape: \u{110000}`},
+	}
+
+	for _, test := range tests {
+		l := New(test.input)
+		for tok, _ := l.NextToken(); tok.Type != token.EOF; tok, _ = l.NextToken() {
+		}
+
+		if len(l.Errors()) != 1 {
+			t.Errorf("wrong number of errors for %s. expected: 1 got: %d (%v)", test.input, len(l.Errors()), l.Errors())
+			continue
+		}
+
+		if l.Errors()[0].Error() != test.expected {
+			t.Errorf("wrong error. expected: %q got: %q", test.expected, l.Errors()[0].Error())
+		}
+	}
+}
+
+func TestNextTokenTemplate(t *testing.T) {
+	input := "`Hi, ${user.name}! ${`n=${n + 1}`} {${m[\"a\"]}}` + `\\${x} \\``"
+
+	tests := []ExpectedToken{
+		{token.TEMPLATE_HEAD, "Hi, "},
+		{token.IDENT, "user"},
+		{token.DOT, "."},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_MIDDLE, "! "},
+		{token.TEMPLATE_HEAD, "n="},
+		{token.IDENT, "n"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_MIDDLE, " {"},
+		{token.IDENT, "m"},
+		{token.LBRACKET, "["},
+		{token.STRING, "a"},
+		{token.RBRACKET, "]"},
+		{token.TEMPLATE_TAIL, "}"},
+		{token.PLUS, "+"},
+		{token.TEMPLATE, "${x} `"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, test := range tests {
+		tok, _ := l.NextToken()
+
+		if tok.Type != test.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong: expected=%q, got=%q",
+				i, test.expectedType, tok.Type)
+		}
+
+		if tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong: expected=%q, got=%q",
+				i, test.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %v", l.Errors())
+	}
+}
@@ -0,0 +1,218 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+var Builtins = map[string]*Builtin{
+	"len": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments: %d want: 1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *String:
+				return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *Array:
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *HashMap:
+				return &Integer{Value: int64(len(arg.Pairs))}
+			default:
+				return NewError("type of argument not supported: %s", arg.Type())
+			}
+		},
+	},
+	"push": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 2 {
+				return NewError("wrong number of arguments: %d want: 2", len(args))
+			}
+
+			if args[0].Type() != ARRAY_OBJ {
+				return NewError("first argument must be ARRAY, got: %s", args[0].Type())
+			}
+
+			args[0].(*Array).Elements = append(args[0].(*Array).Elements, args[1])
+			return args[0]
+		},
+	},
+	"first": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments: %d want: 1", len(args))
+			}
+
+			if args[0].Type() != ARRAY_OBJ {
+				return NewError("argument must be ARRAY, got: %s", args[0].Type())
+			}
+
+			arr := args[0].(*Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+
+			return NULL
+		},
+	},
+	"last": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments: %d want: 1", len(args))
+			}
+
+			if args[0].Type() != ARRAY_OBJ {
+				return NewError("argument must be ARRAY, got: %s", args[0].Type())
+			}
+
+			arr := args[0].(*Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[len(arr.Elements)-1]
+			}
+
+			return NULL
+		},
+	},
+	"intToString": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments: %d want: 1", len(args))
+			}
+
+			if args[0].Type() != INTEGER_OBJ {
+				return NewError("argument must be INTEGER, got: %s", args[0].Type())
+			}
+
+			number := args[0].(*Integer).Value
+
+			return &String{Value: strconv.FormatInt(number, 10)}
+		},
+	},
+	"stringToInt": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 1 {
+				return NewError("wrong number of arguments: %d want: 1", len(args))
+			}
+
+			if args[0].Type() != STRING_OBJ {
+				return NewError("argument must be STRING, got: %s", args[0].Type())
+			}
+
+			str := args[0].(*String).Value
+
+			number, err := strconv.ParseInt(str, 10, 64)
+			if err != nil {
+				return NewError("string to int convert error: %s", err.Error())
+			}
+
+			return &Integer{Value: number}
+		},
+	},
+}
+
+// maximum length (in bytes) of string built by builtins (repeat, padLeft, padRight, replaceRegex)
+const maxBuiltinStringSize = 1 << 26
+
+// output of builtins which build text: strings.Builder, bytes.Buffer or sizeCounter
+type textWriter interface {
+	io.Writer
+	io.StringWriter
+	io.ByteWriter
+}
+
+// counts length of written text, Size of builtin writes result to it instead of building string
+type sizeCounter struct {
+	size int64
+}
+
+func (c *sizeCounter) Write(p []byte) (int, error) {
+	c.size += int64(len(p))
+	return len(p), nil
+}
+
+func (c *sizeCounter) WriteString(s string) (int, error) {
+	c.size += int64(len(s))
+	return len(s), nil
+}
+
+func (c *sizeCounter) WriteByte(byte) error {
+	c.size++
+	return nil
+}
+
+// length of text of object in bytes (see toText), strings are not copied
+func textSize(obj Object) int64 {
+	return int64(len(toText(obj)))
+}
+
+var ordinals = []string{"first", "second", "third", "fourth", "fifth"}
+
+// adds group of builtins, called from init of files with builtins
+func register(group map[string]*Builtin) {
+	for name, builtin := range group {
+		Builtins[name] = builtin
+	}
+}
+
+// returns error if number of arguments is less than min or greater than max (max < 0 - no upper bound)
+func checkArgsCount(args []Object, min, max int) Object {
+	switch {
+	case min == max && len(args) != min:
+		return NewError("wrong number of arguments: %d want: %d", len(args), min)
+	case max < 0 && len(args) < min:
+		return NewError("wrong number of arguments: %d want at least: %d", len(args), min)
+	case max >= 0 && (len(args) < min || len(args) > max):
+		return NewError("wrong number of arguments: %d want: %d-%d", len(args), min, max)
+	default:
+		return nil
+	}
+}
+
+// returns error if argument with index id is not of type t, builtins are accepted as FUNCTION
+func checkArgType(args []Object, id int, t ObjectType) Object {
+	if args[id].Type() == t || t == FUNCTION_OBJ && args[id].Type() == BUILTIN_OBJ {
+		return nil
+	}
+
+	return argTypeError(args, id, string(t))
+}
+
+// error of wrong type of argument with index id, want - description of expected types
+func argTypeError(args []Object, id int, want string) Object {
+	if len(args) == 1 {
+		return NewError("argument must be %s, got: %s", want, args[id].Type())
+	}
+
+	name := fmt.Sprintf("argument %d", id+1)
+	if id < len(ordinals) {
+		name = ordinals[id] + " argument"
+	}
+
+	return NewError("%s must be %s, got: %s", name, want, args[id].Type())
+}
+
+// checks number and types of arguments, types are checked for passed arguments only, empty type - any
+func checkArgs(args []Object, min, max int, types ...ObjectType) Object {
+	if err := checkArgsCount(args, min, max); err != nil {
+		return err
+	}
+
+	for id, t := range types {
+		if id >= len(args) {
+			break
+		}
+
+		if t == "" {
+			continue
+		}
+
+		if err := checkArgType(args, id, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,459 @@
+package object
+
+import (
+	"math"
+	"sort"
+)
+
+// maximum number of elements in array built by range
+const maxBuiltinArraySize = 1 << 24
+
+func init() {
+	register(arrayBuiltins)
+}
+
+// builtins don't change arrays passed as arguments, they return new arrays
+var arrayBuiltins = map[string]*Builtin{
+	"map": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			elements := args[0].(*Array).Elements
+			result := make([]Object, len(elements))
+			for id, el := range elements {
+				res := call(args[1], el)
+				if isError(res) {
+					return res
+				}
+				result[id] = res
+			}
+
+			return &Array{Elements: result}
+		},
+	},
+	"filter": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			result := []Object{}
+			for _, el := range args[0].(*Array).Elements {
+				ok, err := callPredicate(call, args[1], el)
+				if err != nil {
+					return err
+				}
+
+				if ok {
+					result = append(result, el)
+				}
+			}
+
+			return &Array{Elements: result}
+		},
+	},
+	"reduce": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 3, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			// without initial value the first element is used
+			elements := args[0].(*Array).Elements
+			var acc Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else {
+				if len(elements) == 0 {
+					return NewError("reduce of empty array without initial value")
+				}
+				acc = elements[0]
+				elements = elements[1:]
+			}
+
+			for _, el := range elements {
+				acc = call(args[1], acc, el)
+				if isError(acc) {
+					return acc
+				}
+			}
+
+			return acc
+		},
+	},
+	"find": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			for _, el := range args[0].(*Array).Elements {
+				ok, err := callPredicate(call, args[1], el)
+				if err != nil {
+					return err
+				}
+
+				if ok {
+					return el
+				}
+			}
+
+			return NULL
+		},
+	},
+	"any": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			for _, el := range args[0].(*Array).Elements {
+				ok, err := callPredicate(call, args[1], el)
+				if err != nil {
+					return err
+				}
+
+				if ok {
+					return TRUE
+				}
+			}
+
+			return FALSE
+		},
+	},
+	"all": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 2, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			for _, el := range args[0].(*Array).Elements {
+				ok, err := callPredicate(call, args[1], el)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					return FALSE
+				}
+			}
+
+			return TRUE
+		},
+	},
+	"sort": {
+		CallerFn: func(call Caller, args ...Object) Object {
+			if err := checkArgs(args, 1, 2, ARRAY_OBJ, FUNCTION_OBJ); err != nil {
+				return err
+			}
+
+			elements := append([]Object{}, args[0].(*Array).Elements...)
+
+			// the first error stops comparisons
+			var err Object
+			sort.SliceStable(elements, func(i, j int) bool {
+				if err != nil {
+					return false
+				}
+
+				var less bool
+				if len(args) == 2 {
+					less, err = callComparator(call, args[1], elements[i], elements[j])
+				} else {
+					less, err = compare(elements[i], elements[j])
+				}
+
+				return less
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"reverse": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 1, 1); err != nil {
+				return err
+			}
+
+			switch arg := args[0].(type) {
+			case *Array:
+				elements := make([]Object, len(arg.Elements))
+				for id, el := range arg.Elements {
+					elements[len(elements)-1-id] = el
+				}
+
+				return &Array{Elements: elements}
+			case *String:
+				runes := []rune(arg.Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+
+				return &String{Value: string(runes)}
+			default:
+				return NewError("type of argument not supported: %s", arg.Type())
+			}
+		},
+	},
+	"slice": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 3, "", INTEGER_OBJ, INTEGER_OBJ); err != nil {
+				return err
+			}
+
+			if args[0].Type() != ARRAY_OBJ && args[0].Type() != STRING_OBJ {
+				return argTypeError(args, 0, "ARRAY or STRING")
+			}
+
+			// slice(a, start, end) is a[start:end]
+			end := Object(NULL)
+			if len(args) == 3 {
+				end = args[2]
+			}
+
+			return SliceOp(args[0], args[1], end)
+		},
+	},
+	"concat": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 1, -1); err != nil {
+				return err
+			}
+
+			elements := []Object{}
+			for id := range args {
+				if err := checkArgType(args, id, ARRAY_OBJ); err != nil {
+					return err
+				}
+
+				elements = append(elements, args[id].(*Array).Elements...)
+			}
+
+			return &Array{Elements: elements}
+		},
+		Size: func(args ...Object) int64 {
+			size := int64(0)
+			for _, arg := range args {
+				if arr, ok := arg.(*Array); ok {
+					size += int64(len(arr.Elements))
+				}
+			}
+
+			return size
+		},
+	},
+	"unique": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, ARRAY_OBJ); err != nil {
+				return err
+			}
+
+			seen := map[HashKey]bool{}
+			var other []Object // values which are not hashable, compared by equals
+
+			elements := []Object{}
+		next:
+			for _, el := range args[0].(*Array).Elements {
+				if key, ok := uniqueKey(el); ok {
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+				} else {
+					for _, o := range other {
+						if equals(o, el) {
+							continue next
+						}
+					}
+					other = append(other, el)
+				}
+
+				elements = append(elements, el)
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"range": {
+		Fn: func(args ...Object) Object {
+			start, step, count, err := rangeArgs(args)
+			if err != nil {
+				return err
+			}
+
+			if count > maxBuiltinArraySize {
+				return NewError("range is too large: %d elements", count)
+			}
+
+			elements := make([]Object, count)
+			for id := range elements {
+				elements[id] = &Integer{Value: start + int64(id)*step}
+			}
+
+			return &Array{Elements: elements}
+		},
+		Size: func(args ...Object) int64 {
+			_, _, count, err := rangeArgs(args)
+			if err != nil || count > math.MaxInt64 {
+				return 0
+			}
+
+			return int64(count)
+		},
+	},
+	"indexOf": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 2, 2); err != nil {
+				return err
+			}
+
+			switch arg := args[0].(type) {
+			case *Array:
+				return &Integer{Value: arrayIndexOf(arg, args[1])}
+			case *String:
+				if err := checkArgType(args, 1, STRING_OBJ); err != nil {
+					return err
+				}
+
+				return &Integer{Value: stringIndexOf(arg.Value, args[1].(*String).Value)}
+			default:
+				return argTypeError(args, 0, "ARRAY or STRING")
+			}
+		},
+	},
+	"contains": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 2, 2); err != nil {
+				return err
+			}
+
+			switch arg := args[0].(type) {
+			case *Array:
+				return boolToBooleanObj(arrayIndexOf(arg, args[1]) >= 0)
+			case *String:
+				if err := checkArgType(args, 1, STRING_OBJ); err != nil {
+					return err
+				}
+
+				return boolToBooleanObj(stringIndexOf(arg.Value, args[1].(*String).Value) >= 0)
+			default:
+				return argTypeError(args, 0, "ARRAY or STRING")
+			}
+		},
+	},
+}
+
+// calls fn with element, result must be BOOLEAN
+func callPredicate(call Caller, fn, el Object) (bool, Object) {
+	res := call(fn, el)
+	if isError(res) {
+		return false, res
+	}
+
+	if res != TRUE && res != FALSE {
+		return false, NewError("function must return BOOLEAN, got: %s", res.Type())
+	}
+
+	return res == TRUE, nil
+}
+
+// calls comparator of sort, negative number means that a is less than b
+func callComparator(call Caller, fn, a, b Object) (bool, Object) {
+	res := call(fn, a, b)
+	if isError(res) {
+		return false, res
+	}
+
+	if !isNumber(res) {
+		return false, NewError("comparator must return INTEGER or FLOAT, got: %s", res.Type())
+	}
+
+	return toFloat(res) < 0, nil
+}
+
+// default order of sort, as operator <
+func compare(a, b Object) (bool, Object) {
+	res := InfixOp("<", a, b)
+	if isError(res) {
+		return false, res
+	}
+
+	return res == TRUE, nil
+}
+
+// equality of values for indexOf, contains and unique: as operator ==,
+// but values of different types are not equal instead of error
+func equals(a, b Object) bool {
+	if a.Type() != b.Type() && !(isNumeric(a) && isNumeric(b)) {
+		return false
+	}
+
+	return InfixOp("==", a, b) == TRUE
+}
+
+func arrayIndexOf(arr *Array, value Object) int64 {
+	for id, el := range arr.Elements {
+		if equals(el, value) {
+			return int64(id)
+		}
+	}
+
+	return -1
+}
+
+// key of value in unique, whole numbers have keys of integers (see HashKey of Float and Decimal)
+func uniqueKey(obj Object) (HashKey, bool) {
+	// 1.5d is equal to 1.5
+	if d, ok := obj.(*Decimal); ok {
+		if f, exact := d.Rat().Float64(); exact {
+			return (&Float{Value: f}).HashKey(), true
+		}
+	}
+
+	h, ok := obj.(Hashable)
+	if !ok {
+		return HashKey{}, false
+	}
+
+	return h.HashKey(), true
+}
+
+// range(end), range(start, end), range(start, end, step): first element, step and number of elements
+func rangeArgs(args []Object) (int64, int64, uint64, Object) {
+	if err := checkArgs(args, 1, 3, INTEGER_OBJ, INTEGER_OBJ, INTEGER_OBJ); err != nil {
+		return 0, 0, 0, err
+	}
+
+	var start, end, step int64 = 0, 0, 1
+	switch len(args) {
+	case 1:
+		end = args[0].(*Integer).Value
+	case 3:
+		step = args[2].(*Integer).Value
+		fallthrough
+	case 2:
+		start = args[0].(*Integer).Value
+		end = args[1].(*Integer).Value
+	}
+
+	if step == 0 {
+		return 0, 0, 0, NewError("step must not be zero")
+	}
+
+	// unsigned arithmetic doesn't overflow on large bounds
+	var count uint64
+	switch {
+	case step > 0 && start < end:
+		count = (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > end:
+		count = (uint64(start)-uint64(end)-1)/uint64(-step) + 1
+	}
+
+	return start, step, count, nil
+}
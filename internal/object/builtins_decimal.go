@@ -0,0 +1,127 @@
+package object
+
+import "math/big"
+
+func init() {
+	register(decimalBuiltins)
+}
+
+var decimalBuiltins = map[string]*Builtin{
+	"decimal": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 1, 1); err != nil {
+				return err
+			}
+
+			// decimal("19.99"), decimal(5), decimal(0.1)
+			s, ok := args[0].(*String)
+			if !ok {
+				d, err := toDecimal(args[0])
+				if err != nil {
+					return err
+				}
+				return d
+			}
+
+			d, err := ParseDecimal(s.Value)
+			if err != nil {
+				return NewError("decimal: %s", err.Error())
+			}
+
+			return d
+		},
+	},
+	"divide": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 3, 4, "", "", INTEGER_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			// divide(a, b, scale, mode = "halfUp")
+			a, err := decimalArg(args, 0)
+			if err != nil {
+				return err
+			}
+
+			b, err := decimalArg(args, 1)
+			if err != nil {
+				return err
+			}
+
+			scale, err := scaleArg(args, 2)
+			if err != nil {
+				return err
+			}
+
+			mode, err := roundingModeArg(args, 3)
+			if err != nil {
+				return err
+			}
+
+			return DivideDecimal(a, b, scale, mode)
+		},
+	},
+}
+
+// INTEGER or DECIMAL argument as decimal, FLOAT is not accepted: it is not exact
+func decimalArg(args []Object, id int) (*Decimal, Object) {
+	if args[id].Type() != INTEGER_OBJ && !isDecimal(args[id]) {
+		return nil, argTypeError(args, id, "INTEGER or DECIMAL")
+	}
+
+	return toDecimal(args[id])
+}
+
+// number of digits after point, from 0 to maxDecimalScale
+func scaleArg(args []Object, id int) (int32, Object) {
+	scale, ok := args[id].(*Integer)
+	if !ok || scale.Value < 0 || scale.Value > maxDecimalScale {
+		return 0, NewError("scale must be INTEGER from 0 to %d, got: %s", maxDecimalScale, args[id].ToString())
+	}
+
+	return int32(scale.Value), nil
+}
+
+// optional rounding mode, halfUp if argument is omitted
+func roundingModeArg(args []Object, id int) (string, Object) {
+	if len(args) <= id {
+		return ROUND_HALF_UP, nil
+	}
+
+	mode, ok := args[id].(*String)
+	if !ok {
+		return "", argTypeError(args, id, STRING_OBJ)
+	}
+
+	if !roundingModes[mode.Value] {
+		return "", NewError("unknown rounding mode: %s", mode.Value)
+	}
+
+	return mode.Value, nil
+}
+
+// round(x, digits = 0, mode = "halfUp") for decimal, result is DECIMAL
+func roundDecimal(args []Object) Object {
+	if err := checkArgsCount(args, 1, 3); err != nil {
+		return err
+	}
+
+	var scale int32
+	if len(args) >= 2 {
+		var err Object
+		if scale, err = scaleArg(args, 1); err != nil {
+			return err
+		}
+	}
+
+	mode, err := roundingModeArg(args, 2)
+	if err != nil {
+		return err
+	}
+
+	return args[0].(*Decimal).Round(scale, mode)
+}
+
+func absDecimal(d *Decimal) *Decimal {
+	return &Decimal{Value: new(big.Int).Abs(d.Value), Scale: d.Scale}
+}
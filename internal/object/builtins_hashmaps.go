@@ -0,0 +1,107 @@
+package object
+
+func init() {
+	register(hashMapBuiltins)
+}
+
+// keys, values and entries are returned in insertion order
+var hashMapBuiltins = map[string]*Builtin{
+	"keys": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, HASH_MAP_OBJ); err != nil {
+				return err
+			}
+
+			pairs := args[0].(*HashMap).Ordered()
+			elements := make([]Object, len(pairs))
+			for id, pair := range pairs {
+				elements[id] = pair.Key
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"values": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, HASH_MAP_OBJ); err != nil {
+				return err
+			}
+
+			pairs := args[0].(*HashMap).Ordered()
+			elements := make([]Object, len(pairs))
+			for id, pair := range pairs {
+				elements[id] = pair.Value
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"entries": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, HASH_MAP_OBJ); err != nil {
+				return err
+			}
+
+			// entry is array [key, value]
+			pairs := args[0].(*HashMap).Ordered()
+			elements := make([]Object, len(pairs))
+			for id, pair := range pairs {
+				elements[id] = &Array{Elements: []Object{pair.Key, pair.Value}}
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"has": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, HASH_MAP_OBJ); err != nil {
+				return err
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return NewError("unusable as hash key: %s", args[1].Type())
+			}
+
+			_, ok = args[0].(*HashMap).Pairs[key.HashKey()]
+			return boolToBooleanObj(ok)
+		},
+	},
+	"delete": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, HASH_MAP_OBJ); err != nil {
+				return err
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return NewError("unusable as hash key: %s", args[1].Type())
+			}
+
+			// changes hash map in place as push does with array
+			args[0].(*HashMap).Delete(key.HashKey())
+			return args[0]
+		},
+	},
+	"merge": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 1, -1); err != nil {
+				return err
+			}
+
+			// values of later hash maps replace values of earlier ones
+			result := NewHashMap(0)
+			for id := range args {
+				if err := checkArgType(args, id, HASH_MAP_OBJ); err != nil {
+					return err
+				}
+
+				for _, pair := range args[id].(*HashMap).Ordered() {
+					result.Set(pair.Key, pair.Value)
+				}
+			}
+
+			return result
+		},
+	},
+}
@@ -0,0 +1,211 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maximum nesting of arrays and hash maps in jsonStringify
+const maxJSONDepth = 1000
+
+func init() {
+	register(jsonBuiltins)
+}
+
+var jsonBuiltins = map[string]*Builtin{
+	"jsonParse": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, STRING_OBJ); err != nil {
+				return err
+			}
+
+			return parseJSON(args[0].(*String).Value)
+		},
+	},
+	"jsonStringify": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 1, 2); err != nil {
+				return err
+			}
+
+			indent, err := jsonIndent(args)
+			if err != nil {
+				return err
+			}
+
+			var out bytes.Buffer
+			if err := writeJSON(&out, args[0], indent, 0, objectPath{}); err != nil {
+				return err
+			}
+
+			return &String{Value: out.String()}
+		},
+		Size: func(args ...Object) int64 {
+			if checkArgsCount(args, 1, 2) != nil {
+				return 0
+			}
+
+			indent, err := jsonIndent(args)
+			if err != nil {
+				return 0
+			}
+
+			var out sizeCounter
+			writeJSON(&out, args[0], indent, 0, objectPath{})
+
+			return out.size
+		},
+	},
+}
+
+// indent of jsonStringify is number of spaces or string
+func jsonIndent(args []Object) (string, Object) {
+	if len(args) < 2 {
+		return "", nil
+	}
+
+	switch arg := args[1].(type) {
+	case *Integer:
+		if arg.Value < 0 || arg.Value > 10 {
+			return "", NewError("indent must be from 0 to 10, got: %d", arg.Value)
+		}
+		return strings.Repeat(" ", int(arg.Value)), nil
+	case *String:
+		return arg.Value, nil
+	default:
+		return "", argTypeError(args, 1, "INTEGER or STRING")
+	}
+}
+
+// objects of hash maps get keys in alphabetical order (see FromGo),
+// integer numbers are INTEGER, others are FLOAT
+func parseJSON(data string) Object {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return NewError("jsonParse: %s", err.Error())
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return NewError("jsonParse: unexpected data after value")
+	}
+
+	obj, err := fromJSONValue(v, false)
+	if err != nil {
+		return NewError("jsonParse: %s", err.Error())
+	}
+
+	return obj
+}
+
+// writes JSON of object, keys of hash maps are converted by ToString and written in insertion order,
+// DATETIME and DURATION are written as strings, cyclic value is error
+func writeJSON(out textWriter, obj Object, indent string, depth int, path objectPath) Object {
+	if depth > maxJSONDepth {
+		return NewError("jsonStringify: nesting is too deep")
+	}
+
+	switch obj := obj.(type) {
+	case *Null:
+		out.WriteString("null")
+	case *Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return NewError("jsonStringify: unsupported value: %s", obj.ToString())
+		}
+		out.WriteString(strconv.FormatFloat(obj.Value, 'g', -1, 64))
+	case *Decimal:
+		// exact number, without rounding to float
+		out.WriteString(obj.String())
+	case *String:
+		writeJSONString(out, obj.Value)
+	case *DateTime:
+		writeJSONString(out, obj.Value.Format(time.RFC3339Nano))
+	case *Duration:
+		writeJSONString(out, obj.Value.String())
+	case *Array:
+		if !path.enter(obj) {
+			return NewError("jsonStringify: cyclic value")
+		}
+		defer path.leave(obj)
+
+		out.WriteByte('[')
+		for id, el := range obj.Elements {
+			if id > 0 {
+				out.WriteByte(',')
+			}
+			writeJSONIndent(out, indent, depth+1)
+
+			if err := writeJSON(out, el, indent, depth+1, path); err != nil {
+				return err
+			}
+		}
+		if len(obj.Elements) != 0 {
+			writeJSONIndent(out, indent, depth)
+		}
+		out.WriteByte(']')
+	case *HashMap:
+		if !path.enter(obj) {
+			return NewError("jsonStringify: cyclic value")
+		}
+		defer path.leave(obj)
+
+		out.WriteByte('{')
+		for id, pair := range obj.Ordered() {
+			if id > 0 {
+				out.WriteByte(',')
+			}
+			writeJSONIndent(out, indent, depth+1)
+
+			writeJSONString(out, toText(pair.Key))
+			out.WriteByte(':')
+			if indent != "" {
+				out.WriteByte(' ')
+			}
+
+			if err := writeJSON(out, pair.Value, indent, depth+1, path); err != nil {
+				return err
+			}
+		}
+		if len(obj.Pairs) != 0 {
+			writeJSONIndent(out, indent, depth)
+		}
+		out.WriteByte('}')
+	default:
+		return NewError("jsonStringify: unsupported type: %s", obj.Type())
+	}
+
+	return nil
+}
+
+// new line and indent of level depth, nothing if output is not indented
+func writeJSONIndent(out textWriter, indent string, depth int) {
+	if indent == "" {
+		return
+	}
+
+	out.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		out.WriteString(indent)
+	}
+}
+
+// writes quoted string, HTML characters are not escaped
+func writeJSONString(out textWriter, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// encoding of string can't fail
+	_ = enc.Encode(s)
+	out.Write(buf.Bytes()[:buf.Len()-1]) // without new line added by Encode
+}
@@ -0,0 +1,369 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+)
+
+// source of random numbers for random and randomInt
+type Random interface {
+	Float64() float64
+	Int63n(n int64) int64
+}
+
+// functions of package math/rand, safe for concurrent use
+type globalRandom struct{}
+
+func (globalRandom) Float64() float64     { return rand.Float64() }
+func (globalRandom) Int63n(n int64) int64 { return rand.Int63n(n) }
+
+func init() {
+	register(mathBuiltins)
+	register(RandomBuiltins(globalRandom{}))
+}
+
+// integer overflow in builtins is always error
+var mathBuiltins = map[string]*Builtin{
+	"abs": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumericArgs(args, 1, 1); err != nil {
+				return err
+			}
+
+			switch arg := args[0].(type) {
+			case *Decimal:
+				return absDecimal(arg)
+			case *Integer:
+				if arg.Value >= 0 {
+					return arg
+				}
+				return CheckedPrefixOp("-", arg)
+			default:
+				return &Float{Value: math.Abs(toFloat(arg))}
+			}
+		},
+	},
+	"min": {
+		Fn: func(args ...Object) Object {
+			return extremum(args, "<")
+		},
+	},
+	"max": {
+		Fn: func(args ...Object) Object {
+			return extremum(args, ">")
+		},
+	},
+	"pow": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumberArgs(args, 2, 2); err != nil {
+				return err
+			}
+
+			// integer result for integer base and non negative integer exponent
+			base, bok := args[0].(*Integer)
+			exp, eok := args[1].(*Integer)
+			if bok && eok && exp.Value >= 0 {
+				return intPow(base.Value, exp.Value)
+			}
+
+			return &Float{Value: math.Pow(toFloat(args[0]), toFloat(args[1]))}
+		},
+	},
+	"sqrt": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumberArgs(args, 1, 1); err != nil {
+				return err
+			}
+
+			x := toFloat(args[0])
+			if x < 0 {
+				return NewError("square root of negative number: %s", args[0].ToString())
+			}
+
+			return &Float{Value: math.Sqrt(x)}
+		},
+	},
+	"floor": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumericArgs(args, 1, 1); err != nil {
+				return err
+			}
+
+			if d, ok := args[0].(*Decimal); ok {
+				return d.Round(0, ROUND_FLOOR)
+			}
+
+			return floatToInt(math.Floor(toFloat(args[0])), args[0])
+		},
+	},
+	"ceil": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumericArgs(args, 1, 1); err != nil {
+				return err
+			}
+
+			if d, ok := args[0].(*Decimal); ok {
+				return d.Round(0, ROUND_CEILING)
+			}
+
+			return floatToInt(math.Ceil(toFloat(args[0])), args[0])
+		},
+	},
+	"round": {
+		Fn: func(args ...Object) Object {
+			if len(args) != 0 && isDecimal(args[0]) {
+				return roundDecimal(args)
+			}
+
+			if err := checkNumberArgs(args, 1, 2); err != nil {
+				return err
+			}
+
+			// round(x) is INTEGER, round(x, digits) is FLOAT, halves are rounded away from zero
+			if len(args) == 1 {
+				return floatToInt(math.Round(toFloat(args[0])), args[0])
+			}
+
+			digits, ok := args[1].(*Integer)
+			if !ok || digits.Value < 0 || digits.Value > 15 {
+				return NewError("digits must be INTEGER from 0 to 15, got: %s", args[1].ToString())
+			}
+
+			scale := math.Pow(10, float64(digits.Value))
+			return &Float{Value: math.Round(toFloat(args[0])*scale) / scale}
+		},
+	},
+	"clamp": {
+		Fn: func(args ...Object) Object {
+			if err := checkNumberArgs(args, 3, 3); err != nil {
+				return err
+			}
+
+			// clamp(x, low, high)
+			x, low, high := args[0], args[1], args[2]
+			if toFloat(low) > toFloat(high) {
+				return NewError("low bound is greater than high bound: %s > %s", low.ToString(), high.ToString())
+			}
+
+			switch {
+			case toFloat(x) < toFloat(low):
+				return low
+			case toFloat(x) > toFloat(high):
+				return high
+			default:
+				return x
+			}
+		},
+	},
+	"sum": {
+		Fn: func(args ...Object) Object {
+			elements, err := numberElements(args)
+			if err != nil {
+				return err
+			}
+
+			var sum Object = &Integer{Value: 0}
+			for _, el := range elements {
+				sum = CheckedInfixOp("+", sum, el)
+				if isError(sum) {
+					return sum
+				}
+			}
+
+			return sum
+		},
+	},
+	"avg": {
+		Fn: func(args ...Object) Object {
+			elements, err := numberElements(args)
+			if err != nil {
+				return err
+			}
+
+			if len(elements) == 0 {
+				return NewError("average of empty array")
+			}
+
+			for _, el := range elements {
+				if isDecimal(el) {
+					return decimalAvg(elements)
+				}
+			}
+
+			// floats don't overflow on large integers
+			var sum float64
+			for _, el := range elements {
+				sum += toFloat(el)
+			}
+
+			return &Float{Value: sum / float64(len(elements))}
+		},
+	},
+}
+
+// builtins random and randomInt with given source of random numbers.
+// Embedder can set them to env to get reproducible numbers (source with fixed seed)
+func RandomBuiltins(r Random) map[string]*Builtin {
+	return map[string]*Builtin{
+		"random": {
+			Fn: func(args ...Object) Object {
+				if err := checkArgsCount(args, 0, 0); err != nil {
+					return err
+				}
+
+				// from 0 to 1, 1 is not included
+				return &Float{Value: r.Float64()}
+			},
+		},
+		"randomInt": {
+			Fn: func(args ...Object) Object {
+				if err := checkArgs(args, 2, 2, INTEGER_OBJ, INTEGER_OBJ); err != nil {
+					return err
+				}
+
+				// randomInt(min, max), max is included
+				low, high := args[0].(*Integer).Value, args[1].(*Integer).Value
+				if low > high {
+					return NewError("min is greater than max: %d > %d", low, high)
+				}
+
+				if overflows("-", high, low) || high-low == math.MaxInt64 {
+					return NewError("range is too large: %d - %d", low, high)
+				}
+
+				return &Integer{Value: low + r.Int63n(high-low+1)}
+			},
+		},
+	}
+}
+
+// checks number of arguments, all arguments must be INTEGER or FLOAT
+func checkNumberArgs(args []Object, min, max int) Object {
+	if err := checkArgsCount(args, min, max); err != nil {
+		return err
+	}
+
+	for id, arg := range args {
+		if !isNumber(arg) {
+			return argTypeError(args, id, "INTEGER or FLOAT")
+		}
+	}
+
+	return nil
+}
+
+// min(a, b, ...) or min(array), the first of equal values is returned
+func extremum(args []Object, op string) Object {
+	elements, err := numberElements(args)
+	if err != nil {
+		return err
+	}
+
+	if len(elements) == 0 {
+		return NewError("empty array")
+	}
+
+	result := elements[0]
+	for _, el := range elements[1:] {
+		cmp := InfixOp(op, el, result)
+		if isError(cmp) {
+			return cmp
+		}
+
+		if cmp == TRUE {
+			result = el
+		}
+	}
+
+	return result
+}
+
+// exact average, decimals can't be mixed with floats
+func decimalAvg(elements []Object) Object {
+	var sum Object = &Decimal{Value: new(big.Int)}
+	for _, el := range elements {
+		sum = InfixOp("+", sum, el)
+		if isError(sum) {
+			return sum
+		}
+	}
+
+	return InfixOp("/", sum, &Integer{Value: int64(len(elements))})
+}
+
+// as checkNumberArgs, but DECIMAL is allowed too
+func checkNumericArgs(args []Object, min, max int) Object {
+	if err := checkArgsCount(args, min, max); err != nil {
+		return err
+	}
+
+	for id, arg := range args {
+		if !isNumeric(arg) {
+			return argTypeError(args, id, "INTEGER, FLOAT or DECIMAL")
+		}
+	}
+
+	return nil
+}
+
+// numbers from arguments: array of numbers or numbers (at least one)
+func numberElements(args []Object) ([]Object, Object) {
+	if err := checkArgsCount(args, 1, -1); err != nil {
+		return nil, err
+	}
+
+	if arr, ok := args[0].(*Array); ok && len(args) == 1 {
+		for id, el := range arr.Elements {
+			if !isNumeric(el) {
+				return nil, NewError("element %d must be INTEGER, FLOAT or DECIMAL, got: %s", id, el.Type())
+			}
+		}
+
+		return arr.Elements, nil
+	}
+
+	for id, arg := range args {
+		if !isNumeric(arg) {
+			return nil, argTypeError(args, id, "INTEGER, FLOAT or DECIMAL")
+		}
+	}
+
+	return args, nil
+}
+
+// result of floor, ceil and round, integer argument is returned as is
+func floatToInt(f float64, arg Object) Object {
+	if arg.Type() == INTEGER_OBJ {
+		return arg
+	}
+
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return NewError("value out of range of INTEGER: %s", arg.ToString())
+	}
+
+	return &Integer{Value: int64(f)}
+}
+
+// exponentiation by squaring with overflow check
+func intPow(base, exp int64) Object {
+	result := int64(1)
+	for exp > 0 {
+		if exp&1 == 1 {
+			if overflows("*", result, base) {
+				return NewError("integer overflow: pow")
+			}
+			result *= base
+		}
+
+		exp >>= 1
+		if exp > 0 {
+			if overflows("*", base, base) {
+				return NewError("integer overflow: pow")
+			}
+			base *= base
+		}
+	}
+
+	return &Integer{Value: result}
+}
@@ -0,0 +1,164 @@
+package object
+
+import (
+	"regexp"
+	"sync"
+)
+
+// maximum number of compiled patterns in cache, cache is cleared when it is full
+const maxRegexCacheSize = 256
+
+// compiled patterns, builtins are shared by all executions so cache is protected by mutex
+var regexCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: map[string]*regexp.Regexp{}}
+
+func init() {
+	register(regexBuiltins)
+}
+
+// patterns use RE2 syntax (package regexp), matching time is linear in length of string
+var regexBuiltins = map[string]*Builtin{
+	"test": {
+		Fn: func(args ...Object) Object {
+			re, err := regexArgs(args, 2)
+			if err != nil {
+				return err
+			}
+
+			return boolToBooleanObj(re.MatchString(args[0].(*String).Value))
+		},
+	},
+	"match": {
+		Fn: func(args ...Object) Object {
+			re, err := regexArgs(args, 2)
+			if err != nil {
+				return err
+			}
+
+			str := args[0].(*String).Value
+			loc := re.FindStringSubmatchIndex(str)
+			if loc == nil {
+				return NULL
+			}
+
+			return submatches(str, loc)
+		},
+	},
+	"matchAll": {
+		Fn: func(args ...Object) Object {
+			re, err := regexArgs(args, 2)
+			if err != nil {
+				return err
+			}
+
+			str := args[0].(*String).Value
+			matches := []Object{}
+			for _, loc := range re.FindAllStringSubmatchIndex(str, -1) {
+				matches = append(matches, submatches(str, loc))
+			}
+
+			return &Array{Elements: matches}
+		},
+	},
+	"replaceRegex": {
+		Fn: func(args ...Object) Object {
+			re, err := regexArgs(args, 3)
+			if err != nil {
+				return err
+			}
+
+			// $1, ${name} in replacement are replaced by groups
+			str, repl := args[0].(*String).Value, args[2].(*String).Value
+			if regexReplaceSize(re, str, repl) > maxBuiltinStringSize {
+				return NewError("result is too long")
+			}
+
+			return &String{Value: re.ReplaceAllString(str, repl)}
+		},
+		Size: func(args ...Object) int64 {
+			re, err := regexArgs(args, 3)
+			if err != nil {
+				return 0
+			}
+
+			return regexReplaceSize(re, args[0].(*String).Value, args[2].(*String).Value)
+		},
+	},
+	"splitRegex": {
+		Fn: func(args ...Object) Object {
+			re, err := regexArgs(args, 2)
+			if err != nil {
+				return err
+			}
+
+			parts := re.Split(args[0].(*String).Value, -1)
+			elements := make([]Object, len(parts))
+			for id, p := range parts {
+				elements[id] = &String{Value: p}
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+}
+
+// length of result of replaceRegex, replacement is expanded for each match without building result
+func regexReplaceSize(re *regexp.Regexp, str, repl string) int64 {
+	size := int64(len(str))
+	var expanded []byte
+	for _, loc := range re.FindAllStringSubmatchIndex(str, -1) {
+		expanded = re.ExpandString(expanded[:0], repl, str, loc)
+		size += int64(len(expanded)) - int64(loc[1]-loc[0])
+	}
+
+	return size
+}
+
+// checks arguments (string, pattern, string...) and returns compiled pattern
+func regexArgs(args []Object, count int) (*regexp.Regexp, Object) {
+	types := []ObjectType{STRING_OBJ, STRING_OBJ, STRING_OBJ}
+	if err := checkArgs(args, count, count, types[:count]...); err != nil {
+		return nil, err
+	}
+
+	return compileRegex(args[1].(*String).Value)
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, Object) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if re, ok := regexCache.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, NewError("invalid regular expression: %s", err.Error())
+	}
+
+	if len(regexCache.patterns) >= maxRegexCacheSize {
+		regexCache.patterns = map[string]*regexp.Regexp{}
+	}
+	regexCache.patterns[pattern] = re
+
+	return re, nil
+}
+
+// array of match and its groups, groups which didn't participate in match are null
+func submatches(str string, loc []int) Object {
+	elements := make([]Object, len(loc)/2)
+	for id := range elements {
+		start, end := loc[2*id], loc[2*id+1]
+		if start < 0 {
+			elements[id] = NULL
+			continue
+		}
+
+		elements[id] = &String{Value: str[start:end]}
+	}
+
+	return &Array{Elements: elements}
+}
@@ -0,0 +1,338 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	register(stringBuiltins)
+}
+
+var stringBuiltins = map[string]*Builtin{
+	"split": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			// empty separator splits string into characters
+			parts := strings.Split(args[0].(*String).Value, args[1].(*String).Value)
+
+			elements := make([]Object, len(parts))
+			for id, p := range parts {
+				elements[id] = &String{Value: p}
+			}
+
+			return &Array{Elements: elements}
+		},
+	},
+	"join": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 2, ARRAY_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			sep := ""
+			if len(args) == 2 {
+				sep = args[1].(*String).Value
+			}
+
+			elements := args[0].(*Array).Elements
+			parts := make([]string, len(elements))
+			for id, el := range elements {
+				parts[id] = toText(el)
+			}
+
+			return &String{Value: strings.Join(parts, sep)}
+		},
+		Size: func(args ...Object) int64 {
+			if checkArgs(args, 1, 2, ARRAY_OBJ, STRING_OBJ) != nil {
+				return 0
+			}
+
+			elements := args[0].(*Array).Elements
+			if len(elements) == 0 {
+				return 0
+			}
+
+			size := int64(0)
+			if len(args) == 2 {
+				size = int64(len(args[1].(*String).Value)) * int64(len(elements)-1)
+			}
+			for _, el := range elements {
+				size += textSize(el)
+			}
+
+			return size
+		},
+	},
+	"trim": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 2, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			if len(args) == 2 {
+				return &String{Value: strings.Trim(args[0].(*String).Value, args[1].(*String).Value)}
+			}
+
+			return &String{Value: strings.TrimSpace(args[0].(*String).Value)}
+		},
+	},
+	"upper": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, STRING_OBJ); err != nil {
+				return err
+			}
+
+			return &String{Value: strings.ToUpper(args[0].(*String).Value)}
+		},
+	},
+	"lower": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, STRING_OBJ); err != nil {
+				return err
+			}
+
+			return &String{Value: strings.ToLower(args[0].(*String).Value)}
+		},
+	},
+	"startsWith": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			return boolToBooleanObj(strings.HasPrefix(args[0].(*String).Value, args[1].(*String).Value))
+		},
+	},
+	"endsWith": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			return boolToBooleanObj(strings.HasSuffix(args[0].(*String).Value, args[1].(*String).Value))
+		},
+	},
+	"replace": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 3, 3, STRING_OBJ, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			str := strings.ReplaceAll(args[0].(*String).Value, args[1].(*String).Value, args[2].(*String).Value)
+			return &String{Value: str}
+		},
+		Size: func(args ...Object) int64 {
+			if checkArgs(args, 3, 3, STRING_OBJ, STRING_OBJ, STRING_OBJ) != nil {
+				return 0
+			}
+
+			str, old, repl := args[0].(*String).Value, args[1].(*String).Value, args[2].(*String).Value
+			// empty old matches before every character and at the end, as in strings.ReplaceAll
+			count := int64(strings.Count(str, old))
+
+			return int64(len(str)) + count*(int64(len(repl))-int64(len(old)))
+		},
+	},
+	"substr": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 3, STRING_OBJ, INTEGER_OBJ, INTEGER_OBJ); err != nil {
+				return err
+			}
+
+			// substr(s, start, length) is s[start:start + length]
+			start := args[1].(*Integer).Value
+			end := Object(NULL)
+			if len(args) == 3 {
+				length := args[2].(*Integer).Value
+				if length < 0 {
+					return NewError("length must not be negative: %d", length)
+				}
+				end = &Integer{Value: start + length}
+			}
+
+			return SliceOp(args[0], args[1], end)
+		},
+	},
+	"repeat": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, STRING_OBJ, INTEGER_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*String).Value
+			count := args[1].(*Integer).Value
+			if count < 0 {
+				return NewError("count must not be negative: %d", count)
+			}
+
+			if str != "" && count > int64(maxBuiltinStringSize/len(str)) {
+				return NewError("result is too long")
+			}
+
+			return &String{Value: strings.Repeat(str, int(count))}
+		},
+		Size: func(args ...Object) int64 {
+			if checkArgs(args, 2, 2, STRING_OBJ, INTEGER_OBJ) != nil {
+				return 0
+			}
+
+			size := int64(len(args[0].(*String).Value))
+			count := args[1].(*Integer).Value
+			if size > 0 && count > math.MaxInt64/size {
+				return math.MaxInt64
+			}
+
+			return size * count
+		},
+	},
+	"padLeft": {
+		Fn: func(args ...Object) Object {
+			return pad(args, true)
+		},
+		Size: padSize,
+	},
+	"padRight": {
+		Fn: func(args ...Object) Object {
+			return pad(args, false)
+		},
+		Size: padSize,
+	},
+	"format": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, -1, STRING_OBJ); err != nil {
+				return err
+			}
+
+			var out strings.Builder
+			if err := format(&out, args[0].(*String).Value, args[1:]); err != nil {
+				return err
+			}
+
+			return &String{Value: out.String()}
+		},
+		Size: func(args ...Object) int64 {
+			if checkArgs(args, 1, -1, STRING_OBJ) != nil {
+				return 0
+			}
+
+			var out sizeCounter
+			format(&out, args[0].(*String).Value, args[1:])
+
+			return out.size
+		},
+	},
+}
+
+// index of first occurrence of sub in characters, -1 if not found
+func stringIndexOf(str, sub string) int64 {
+	id := strings.Index(str, sub)
+	if id < 0 {
+		return -1
+	}
+
+	return int64(utf8.RuneCountInString(str[:id]))
+}
+
+// padLeft(s, length, pad = " "), padRight(s, length, pad = " "): adds pad until string has length characters
+func pad(args []Object, left bool) Object {
+	if err := checkArgs(args, 2, 3, STRING_OBJ, INTEGER_OBJ, STRING_OBJ); err != nil {
+		return err
+	}
+
+	str := args[0].(*String).Value
+	length := args[1].(*Integer).Value
+
+	padding := " "
+	if len(args) == 3 {
+		padding = args[2].(*String).Value
+	}
+
+	if padding == "" {
+		return NewError("pad must not be empty")
+	}
+
+	missing := length - int64(utf8.RuneCountInString(str))
+	if missing <= 0 {
+		return args[0]
+	}
+
+	if missing > maxBuiltinStringSize {
+		return NewError("result is too long")
+	}
+
+	runes := []rune(strings.Repeat(padding, int(missing)/utf8.RuneCountInString(padding)+1))[:missing]
+	if left {
+		return &String{Value: string(runes) + str}
+	}
+
+	return &String{Value: str + string(runes)}
+}
+
+// lower bound of length of padded string in bytes, every added character takes at least one byte
+func padSize(args ...Object) int64 {
+	if checkArgs(args, 2, 3, STRING_OBJ, INTEGER_OBJ, STRING_OBJ) != nil {
+		return 0
+	}
+
+	str := args[0].(*String).Value
+	missing := args[1].(*Integer).Value - int64(utf8.RuneCountInString(str))
+	switch {
+	case missing <= 0:
+		return int64(len(str))
+	case missing > math.MaxInt64-int64(len(str)):
+		return math.MaxInt64
+	}
+
+	return int64(len(str)) + missing
+}
+
+// writes template, {} is replaced with next argument and {N} with argument N (from 0), {{ and }} are braces
+func format(out textWriter, tmpl string, args []Object) Object {
+	next := 0
+
+	for i := 0; i < len(tmpl); i++ {
+		ch := tmpl[i]
+
+		switch {
+		case (ch == '{' || ch == '}') && i+1 < len(tmpl) && tmpl[i+1] == ch:
+			out.WriteByte(ch)
+			i++
+		case ch == '{':
+			end := strings.IndexByte(tmpl[i:], '}')
+			if end < 0 {
+				return NewError("format: unclosed {")
+			}
+
+			spec := tmpl[i+1 : i+end]
+			id := next
+			if spec == "" {
+				next++
+			} else {
+				n, err := strconv.Atoi(spec)
+				if err != nil || n < 0 {
+					return NewError("format: invalid placeholder: {%s}", spec)
+				}
+				id = n
+			}
+
+			if id >= len(args) {
+				return NewError("format: no argument for placeholder %d", id)
+			}
+
+			out.WriteString(toText(args[id]))
+			i += end
+		case ch == '}':
+			return NewError("format: unexpected }")
+		default:
+			out.WriteByte(ch)
+		}
+	}
+
+	return nil
+}
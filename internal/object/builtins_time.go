@@ -0,0 +1,151 @@
+package object
+
+import (
+	"time"
+	// timezones are available without tzdata of system
+	_ "time/tzdata"
+)
+
+// names of layouts which can be used instead of Go layouts (2006-01-02 15:04:05)
+var timeLayouts = map[string]string{
+	"RFC3339":  time.RFC3339,
+	"date":     "2006-01-02",
+	"time":     "15:04",
+	"datetime": "2006-01-02 15:04:05",
+}
+
+// returns current time, used by builtin now
+type Clock func() time.Time
+
+func init() {
+	register(timeBuiltins)
+}
+
+var timeBuiltins = map[string]*Builtin{
+	"now": NowBuiltin(func() time.Time { return time.Now().UTC() }),
+	"parseTime": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 3, STRING_OBJ, STRING_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			// parseTime(str, layout = RFC3339, timezone = "UTC"), timezone is used if str has no offset
+			layout := time.RFC3339
+			if len(args) >= 2 {
+				layout = timeLayout(args[1].(*String).Value)
+			}
+
+			loc := time.UTC
+			if len(args) == 3 {
+				var err Object
+				if loc, err = loadLocation(args[2].(*String).Value); err != nil {
+					return err
+				}
+			}
+
+			t, err := time.ParseInLocation(layout, args[0].(*String).Value, loc)
+			if err != nil {
+				return NewError("parseTime: %s", err.Error())
+			}
+
+			return &DateTime{Value: t}
+		},
+	},
+	"formatTime": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 2, DATETIME_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			layout := time.RFC3339
+			if len(args) == 2 {
+				layout = timeLayout(args[1].(*String).Value)
+			}
+
+			return &String{Value: args[0].(*DateTime).Value.Format(layout)}
+		},
+	},
+	"duration": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 1, 1, STRING_OBJ); err != nil {
+				return err
+			}
+
+			// "1h30m", "90s", "500ms"
+			d, err := time.ParseDuration(args[0].(*String).Value)
+			if err != nil {
+				return NewError("duration: %s", err.Error())
+			}
+
+			return &Duration{Value: d}
+		},
+	},
+	"addDuration": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, DATETIME_OBJ, DURATION_OBJ); err != nil {
+				return err
+			}
+
+			return &DateTime{Value: args[0].(*DateTime).Value.Add(args[1].(*Duration).Value)}
+		},
+	},
+	"diff": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, DATETIME_OBJ, DATETIME_OBJ); err != nil {
+				return err
+			}
+
+			// diff(a, b) = a - b
+			return &Duration{Value: args[0].(*DateTime).Value.Sub(args[1].(*DateTime).Value)}
+		},
+	},
+	"inTimezone": {
+		Fn: func(args ...Object) Object {
+			if err := checkArgs(args, 2, 2, DATETIME_OBJ, STRING_OBJ); err != nil {
+				return err
+			}
+
+			loc, err := loadLocation(args[1].(*String).Value)
+			if err != nil {
+				return err
+			}
+
+			return &DateTime{Value: args[0].(*DateTime).Value.In(loc)}
+		},
+	},
+}
+
+// builtin now with given clock, embedder can set it to env as "now" to replace current time (for tests)
+func NowBuiltin(clock Clock) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			if err := checkArgsCount(args, 0, 0); err != nil {
+				return err
+			}
+
+			return &DateTime{Value: clock()}
+		},
+	}
+}
+
+func timeLayout(name string) string {
+	if layout, ok := timeLayouts[name]; ok {
+		return layout
+	}
+
+	return name
+}
+
+// IANA name of timezone ("Europe/Moscow") or "UTC", local timezone of server is not available
+func loadLocation(name string) (*time.Location, Object) {
+	if name == "" || name == "Local" {
+		return nil, NewError("unknown timezone: %q", name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, NewError("unknown timezone: %q", name)
+	}
+
+	return loc, nil
+}
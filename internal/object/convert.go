@@ -0,0 +1,364 @@
+package object
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	ratType      = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// converts Go value to object:
+//
+//	nil, nil pointer, slice or map -> NULL
+//	integer types                  -> INTEGER (error if uint64 is out of range)
+//	float types                    -> FLOAT
+//	json.Number                    -> INTEGER or FLOAT
+//	*big.Rat                       -> DECIMAL
+//	time.Time, time.Duration       -> DATETIME, DURATION
+//	string, bool                   -> STRING, BOOLEAN
+//	slice, array                   -> ARRAY
+//	map with string or integer keys -> HASH_MAP, keys are sorted
+//	struct                         -> HASH_MAP with exported fields in order of declaration
+//
+// Name of field is taken from tag bql or json (`bql:"name"`), field with tag "-" is skipped.
+// Pointers and interfaces are dereferenced, Object is returned as is.
+func FromGo(v any) (Object, error) {
+	return fromGo(reflect.ValueOf(v))
+}
+
+// as FromGo, but whole floats are converted to INTEGER: values of context and decoded JSON,
+// JSON does not distinguish integers and floats. If decimals is set, other floats are converted
+// to DECIMAL by shortest representation (19.99 -> 19.99d)
+func fromJSONValue(v any, decimals bool) (Object, error) {
+	c := &goConverter{wholeFloats: true, decimals: decimals, path: map[goRef]bool{}}
+	return c.convert(reflect.ValueOf(v))
+}
+
+func fromGo(v reflect.Value) (Object, error) {
+	c := &goConverter{path: map[goRef]bool{}}
+	return c.convert(v)
+}
+
+// state of conversion of Go value to object
+type goConverter struct {
+	wholeFloats bool
+	decimals    bool
+	// pointers, maps and slices on path from root value, Go value can contain itself
+	path map[goRef]bool
+}
+
+type goRef struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func (c *goConverter) convert(v reflect.Value) (Object, error) {
+	if !v.IsValid() {
+		return NULL, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return NULL, nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		ref := goRef{ptr: v.Pointer(), typ: v.Type()}
+		if v.Kind() == reflect.Slice {
+			ref.len = v.Len()
+		}
+
+		if c.path[ref] {
+			return nil, errors.New("cyclic value")
+		}
+		c.path[ref] = true
+		defer delete(c.path, ref)
+	}
+
+	// types with own conversion, Duration must be checked before integer kinds
+	if v.CanInterface() {
+		switch val := v.Interface().(type) {
+		case Object:
+			return val, nil
+		case json.Number:
+			return c.convertNumber(val)
+		case time.Time:
+			return &DateTime{Value: val}, nil
+		case time.Duration:
+			return &Duration{Value: val}, nil
+		case *big.Rat:
+			return ratToDecimal(val)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("value out of range of INTEGER: %d", v.Uint())
+		}
+		return &Integer{Value: int64(v.Uint())}, nil
+
+	case reflect.Float32, reflect.Float64:
+		if !c.wholeFloats {
+			return &Float{Value: v.Float()}, nil
+		}
+
+		obj := convertFloat(v.Float())
+		if f, ok := obj.(*Float); ok && c.decimals {
+			d, err := toDecimal(f)
+			if err != nil {
+				return nil, errors.New(err.(*Error).Message)
+			}
+			return d, nil
+		}
+		return obj, nil
+
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+
+	case reflect.Bool:
+		return boolToBooleanObj(v.Bool()), nil
+
+	case reflect.Slice, reflect.Array:
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			el, err := c.convert(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			elements[i] = el
+		}
+
+		return &Array{Elements: elements}, nil
+
+	case reflect.Map:
+		return c.mapToHashMap(v)
+
+	case reflect.Struct:
+		return c.structToHashMap(v)
+
+	case reflect.Pointer, reflect.Interface:
+		return c.convert(v.Elem())
+
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", v.Type())
+	}
+}
+
+func (c *goConverter) convertNumber(n json.Number) (Object, error) {
+	if i, err := n.Int64(); err == nil {
+		return &Integer{Value: i}, nil
+	}
+
+	if c.decimals {
+		d, err := ParseDecimal(n.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", n)
+		}
+		return d, nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", n)
+	}
+
+	return &Float{Value: f}, nil
+}
+
+// order of Go map is random, keys are sorted to get the same order of hash map
+func (c *goConverter) mapToHashMap(v reflect.Value) (Object, error) {
+	keys := v.MapKeys()
+
+	switch v.Type().Key().Kind() {
+	case reflect.String:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Int() < keys[j].Int() })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sort.Slice(keys, func(i, j int) bool { return keys[i].Uint() < keys[j].Uint() })
+	default:
+		return nil, fmt.Errorf("unsupported type of map key: %s", v.Type().Key())
+	}
+
+	hashMap := NewHashMap(len(keys))
+	for _, key := range keys {
+		k, err := fromGo(key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := c.convert(v.MapIndex(key))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.ToString(), err)
+		}
+
+		hashMap.Set(k, value)
+	}
+
+	return hashMap, nil
+}
+
+func (c *goConverter) structToHashMap(v reflect.Value) (Object, error) {
+	fields := structFields(v.Type())
+
+	hashMap := NewHashMap(len(fields))
+	for _, f := range fields {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// field of nil embedded pointer
+			continue
+		}
+
+		value, err := c.convert(fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.name, err)
+		}
+
+		hashMap.Set(&String{Value: f.name}, value)
+	}
+
+	return hashMap, nil
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// exported fields of struct including fields of embedded structs
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && indirect(f.Type).Kind() == reflect.Struct && fieldTag(f) == "" {
+			continue
+		}
+
+		name := fieldTag(f)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, structField{name: name, index: f.Index})
+	}
+
+	return fields
+}
+
+// name from tag bql or json without options (omitempty)
+func fieldTag(f reflect.StructField) string {
+	tag, ok := f.Tag.Lookup("bql")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// converts object to Go value:
+//
+//	INTEGER, FLOAT, DECIMAL -> int64, float64, *big.Rat
+//	STRING, BOOLEAN         -> string, bool
+//	DATETIME, DURATION      -> time.Time, time.Duration
+//	ARRAY                   -> []any
+//	HASH_MAP                -> map[string]any, keys are converted by ToString
+//	NULL                    -> nil
+//
+// Error is returned for ERROR, objects without Go value (functions) and hash maps
+// with keys equal after conversion ({1: "a", "1": "b"}).
+func ToGo(obj Object) (any, error) {
+	return toGo(obj, objectPath{})
+}
+
+func toGo(obj Object, path objectPath) (any, error) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return obj.Value, nil
+	case *Float:
+		return obj.Value, nil
+	case *Decimal:
+		return obj.Rat(), nil
+	case *Boolean:
+		return obj.Value, nil
+	case *String:
+		return obj.Value, nil
+	case *DateTime:
+		return obj.Value, nil
+	case *Duration:
+		return obj.Value, nil
+	case *Null:
+		return nil, nil
+	case *Array:
+		if !path.enter(obj) {
+			return nil, errors.New("cyclic value")
+		}
+		defer path.leave(obj)
+
+		a := make([]any, len(obj.Elements))
+		for id, el := range obj.Elements {
+			v, err := toGo(el, path)
+			if err != nil {
+				return nil, err
+			}
+
+			a[id] = v
+		}
+
+		return a, nil
+	case *HashMap:
+		if !path.enter(obj) {
+			return nil, errors.New("cyclic value")
+		}
+		defer path.leave(obj)
+
+		m := make(map[string]any, len(obj.Pairs))
+		for _, pair := range obj.Ordered() {
+			v, err := toGo(pair.Value, path)
+			if err != nil {
+				return nil, err
+			}
+
+			key := pair.Key.ToString()
+			if _, ok := m[key]; ok {
+				return nil, fmt.Errorf("duplicate key: %s", key)
+			}
+			m[key] = v
+		}
+
+		return m, nil
+	case *Error:
+		return nil, errors.New(obj.ToString())
+	default:
+		return nil, fmt.Errorf("unsupported result type: %s", obj.Type())
+	}
+}
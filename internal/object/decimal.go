@@ -0,0 +1,366 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDecimalScale = 100  // maximum number of digits after point
+	maxDecimalBits  = 4096 // maximum size of unscaled value (about 1200 digits)
+
+	defaultDivisionScale = 16 // number of digits after point in result of operator /
+)
+
+// modes of rounding of decimals
+const (
+	ROUND_HALF_UP   = "halfUp"   // to nearest, halves away from zero (default)
+	ROUND_HALF_DOWN = "halfDown" // to nearest, halves towards zero
+	ROUND_HALF_EVEN = "halfEven" // to nearest, halves to even digit (banker's rounding)
+	ROUND_UP        = "up"       // away from zero
+	ROUND_DOWN      = "down"     // towards zero
+	ROUND_CEILING   = "ceiling"  // towards positive infinity
+	ROUND_FLOOR     = "floor"    // towards negative infinity
+)
+
+var roundingModes = map[string]bool{
+	ROUND_HALF_UP: true, ROUND_HALF_DOWN: true, ROUND_HALF_EVEN: true,
+	ROUND_UP: true, ROUND_DOWN: true, ROUND_CEILING: true, ROUND_FLOOR: true,
+}
+
+var bigTen = big.NewInt(10)
+
+// parses decimal number: "19.99", "-5", "1.5e3", leading and trailing spaces are ignored
+func ParseDecimal(s string) (*Decimal, error) {
+	str := strings.TrimSpace(s)
+
+	mantissa, exp, hasExp := strings.Cut(strings.ToLower(str), "e")
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+
+	digits := strings.TrimLeft(intPart, "+-")
+	if len(intPart)-len(digits) > 1 || digits == "" && frac == "" || !isDigits(digits) || !isDigits(frac) {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+
+	scale := len(frac)
+	if hasExp {
+		e, err := strconv.Atoi(exp)
+		if err != nil || e > maxDecimalScale*10 || e < -maxDecimalScale {
+			return nil, fmt.Errorf("invalid decimal: %q", s)
+		}
+		scale -= e
+	}
+
+	unscaled := intPart + frac
+	if scale < 0 {
+		unscaled += strings.Repeat("0", -scale)
+		scale = 0
+	}
+
+	if scale > maxDecimalScale {
+		return nil, fmt.Errorf("too many digits after point: %q", s)
+	}
+
+	value, ok := new(big.Int).SetString(unscaled, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+
+	return &Decimal{Value: value, Scale: int32(scale)}, nil
+}
+
+func isDigits(s string) bool {
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.Value).String()
+
+	if d.Scale > 0 {
+		if len(digits) <= int(d.Scale) {
+			digits = strings.Repeat("0", int(d.Scale)-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-int(d.Scale)] + "." + digits[len(digits)-int(d.Scale):]
+	}
+
+	if d.Value.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// exact value as rational number
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.Value, pow10(d.Scale))
+}
+
+// decimal with the same value and given greater scale
+func (d *Decimal) rescale(scale int32) *big.Int {
+	if scale <= d.Scale {
+		return d.Value
+	}
+
+	return new(big.Int).Mul(d.Value, pow10(scale-d.Scale))
+}
+
+// decimal without trailing zeros after point
+func (d *Decimal) normalize() *Decimal {
+	value, scale := new(big.Int).Set(d.Value), d.Scale
+
+	rem := new(big.Int)
+	for scale > 0 {
+		q, r := new(big.Int).QuoRem(value, bigTen, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		value = q
+		scale--
+	}
+
+	return &Decimal{Value: value, Scale: scale}
+}
+
+// rounds to scale digits after point
+func (d *Decimal) Round(scale int32, mode string) *Decimal {
+	if scale >= d.Scale {
+		return &Decimal{Value: d.rescale(scale), Scale: scale}
+	}
+
+	return &Decimal{Value: roundQuo(d.Value, pow10(d.Scale-scale), mode), Scale: scale}
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// quotient n / d rounded by mode
+func roundQuo(n, d *big.Int, mode string) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// sign of exact quotient, q is truncated towards zero
+	sign := n.Sign() * d.Sign()
+	half := new(big.Int).Abs(r)
+	half.Lsh(half, 1)
+	cmp := half.CmpAbs(d) // compares 2|r| and |d|
+
+	var inc bool
+	switch mode {
+	case ROUND_UP:
+		inc = true
+	case ROUND_DOWN:
+		inc = false
+	case ROUND_CEILING:
+		inc = sign > 0
+	case ROUND_FLOOR:
+		inc = sign < 0
+	case ROUND_HALF_DOWN:
+		inc = cmp > 0
+	case ROUND_HALF_EVEN:
+		inc = cmp > 0 || cmp == 0 && q.Bit(0) == 1
+	default:
+		inc = cmp >= 0
+	}
+
+	if inc {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+
+	return q
+}
+
+// a / b with scale digits after point, returns error if b is zero
+func DivideDecimal(a, b *Decimal, scale int32, mode string) Object {
+	if b.Value.Sign() == 0 {
+		return NewError("division by zero")
+	}
+
+	// a / b = (a.Value * 10^(b.Scale + scale)) / (b.Value * 10^a.Scale) * 10^-scale
+	n := new(big.Int).Mul(a.Value, pow10(b.Scale+scale))
+	d := new(big.Int).Mul(b.Value, pow10(a.Scale))
+
+	return checkDecimal(&Decimal{Value: roundQuo(n, d, mode), Scale: scale})
+}
+
+// returns error if decimal exceeds limits of size
+func checkDecimal(d *Decimal) Object {
+	if d.Scale > maxDecimalScale {
+		return NewError("decimal precision exceeded: %d digits after point", d.Scale)
+	}
+
+	if d.Value.BitLen() > maxDecimalBits {
+		return NewError("decimal is too large")
+	}
+
+	return d
+}
+
+// converts INTEGER, FLOAT (by shortest representation, 0.1 -> 0.1) or DECIMAL to decimal
+func toDecimal(obj Object) (*Decimal, Object) {
+	switch obj := obj.(type) {
+	case *Decimal:
+		return obj, nil
+	case *Integer:
+		return &Decimal{Value: big.NewInt(obj.Value)}, nil
+	case *Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, NewError("can't convert to DECIMAL: %s", obj.ToString())
+		}
+
+		d, err := ParseDecimal(strconv.FormatFloat(obj.Value, 'f', -1, 64))
+		if err != nil {
+			return nil, NewError("can't convert to DECIMAL: %s", obj.ToString())
+		}
+		return d, nil
+	default:
+		return nil, NewError("can't convert to DECIMAL: %s", obj.Type())
+	}
+}
+
+// exact rational number to decimal, error if it has infinite decimal representation (1/3)
+func ratToDecimal(r *big.Rat) (*Decimal, error) {
+	// denominator must be 2^a * 5^b, scale is max(a, b)
+	denom := new(big.Int).Set(r.Denom())
+	rem := new(big.Int)
+	var twos, fives int32
+	for _, p := range []struct {
+		n     int64
+		count *int32
+	}{{2, &twos}, {5, &fives}} {
+		div := big.NewInt(p.n)
+		for {
+			q, m := new(big.Int).QuoRem(denom, div, rem)
+			if m.Sign() != 0 {
+				break
+			}
+			denom = q
+			*p.count++
+		}
+	}
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("no finite decimal representation: %s", r.String())
+	}
+
+	scale := maxScale(twos, fives)
+
+	if scale > maxDecimalScale {
+		return nil, fmt.Errorf("too many digits after point: %s", r.String())
+	}
+
+	value := new(big.Int).Mul(r.Num(), pow10(scale))
+	value.Quo(value, r.Denom())
+
+	return &Decimal{Value: value, Scale: scale}, nil
+}
+
+func isDecimal(obj Object) bool {
+	return obj.Type() == DECIMAL_OBJ
+}
+
+// INTEGER, FLOAT or DECIMAL
+func isNumeric(obj Object) bool {
+	return isNumber(obj) || isDecimal(obj)
+}
+
+// arithmetic and comparison of decimals, INTEGER operand is converted to DECIMAL.
+// FLOAT is not converted implicitly: it is not exact, only == and != compare exact values (1.5d == 1.5, 0.1d != 0.1)
+func evalDecimalInfixExpr(op string, left Object, right Object) Object {
+	if (op == "==" || op == "!=") && (left.Type() == FLOAT_OBJ || right.Type() == FLOAT_OBJ) {
+		return equalDecimalFloat(op, left, right)
+	}
+
+	if left.Type() != INTEGER_OBJ && !isDecimal(left) || right.Type() != INTEGER_OBJ && !isDecimal(right) {
+		return NewError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	l, _ := toDecimal(left)
+	r, _ := toDecimal(right)
+
+	scale := maxScale(l.Scale, r.Scale)
+
+	switch op {
+	case "+":
+		return checkDecimal(&Decimal{Value: new(big.Int).Add(l.rescale(scale), r.rescale(scale)), Scale: scale})
+	case "-":
+		return checkDecimal(&Decimal{Value: new(big.Int).Sub(l.rescale(scale), r.rescale(scale)), Scale: scale})
+	case "*":
+		return checkDecimal(&Decimal{Value: new(big.Int).Mul(l.Value, r.Value), Scale: l.Scale + r.Scale})
+	case "/":
+		// trailing zeros are removed, but scale is not less than scales of operands: 10.00 / 4 = 2.50,
+		// result is rounded to maxDecimalScale digits
+		divScale := scale + defaultDivisionScale
+		if divScale > maxDecimalScale {
+			divScale = maxDecimalScale
+		}
+
+		res := DivideDecimal(l, r, divScale, ROUND_HALF_UP)
+		d, ok := res.(*Decimal)
+		if !ok {
+			return res
+		}
+
+		d = d.normalize()
+		return d.Round(maxScale(d.Scale, scale), ROUND_HALF_UP)
+	case "%":
+		if r.Value.Sign() == 0 {
+			return NewError("modulo by zero")
+		}
+		return &Decimal{Value: new(big.Int).Rem(l.rescale(scale), r.rescale(scale)), Scale: scale}
+	}
+
+	cmp := l.rescale(scale).Cmp(r.rescale(scale))
+	switch op {
+	case "==":
+		return boolToBooleanObj(cmp == 0)
+	case "!=":
+		return boolToBooleanObj(cmp != 0)
+	case "<":
+		return boolToBooleanObj(cmp < 0)
+	case ">":
+		return boolToBooleanObj(cmp > 0)
+	case "<=":
+		return boolToBooleanObj(cmp <= 0)
+	case ">=":
+		return boolToBooleanObj(cmp >= 0)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// compares exact values of DECIMAL and FLOAT, NaN and infinity are not equal to decimal
+func equalDecimalFloat(op string, left Object, right Object) Object {
+	d, f := left, right
+	if isDecimal(right) {
+		d, f = right, left
+	}
+
+	if !isDecimal(d) {
+		return NewError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	r := new(big.Rat).SetFloat64(f.(*Float).Value)
+	equal := r != nil && d.(*Decimal).Rat().Cmp(r) == 0
+
+	return boolToBooleanObj(equal == (op == "=="))
+}
+
+func maxScale(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
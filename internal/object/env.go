@@ -1,8 +1,10 @@
 package object
 
 type Env struct {
-	store map[string]Object
-	outer *Env
+	store  map[string]Object
+	consts map[string]bool
+	outer  *Env
+	block  bool // env of block, variables assigned without declaration are created in outer env
 }
 
 func NewEnv() *Env {
@@ -15,6 +17,13 @@ func NewEnclosedEnv(outer *Env) *Env {
 	return env
 }
 
+// env of block with declarations (let, const) or of iteration of for loop
+func NewBlockEnv(outer *Env) *Env {
+	env := NewEnclosedEnv(outer)
+	env.block = true
+	return env
+}
+
 func (e *Env) Get(key string) (Object, bool) {
 	obj, ok := e.store[key]
 	if !ok && e.outer != nil {
@@ -23,7 +32,55 @@ func (e *Env) Get(key string) (Object, bool) {
 	return obj, ok
 }
 
+// sets variable of this env
 func (e *Env) Set(key string, val Object) Object {
 	e.store[key] = val
 	return val
 }
+
+// defines variable in this env (let, const), previous variable with the same name is replaced
+func (e *Env) Declare(key string, val Object, constant bool) {
+	e.store[key] = val
+
+	if constant {
+		if e.consts == nil {
+			e.consts = make(map[string]bool)
+		}
+		e.consts[key] = true
+	} else {
+		delete(e.consts, key)
+	}
+}
+
+// changes variable in the nearest env where it is defined,
+// variable that is not defined is created in env of function (or in top level env).
+// Returns error if variable is constant, otherwise nil
+func (e *Env) Assign(key string, val Object) Object {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[key]; ok {
+			if env.consts[key] {
+				return NewError("assignment to constant: %s", key)
+			}
+
+			env.store[key] = val
+			return nil
+		}
+	}
+
+	env := e
+	for env.block && env.outer != nil {
+		env = env.outer
+	}
+	env.store[key] = val
+
+	return nil
+}
+
+// returns variables of this env without outer envs
+func (e *Env) Vars() map[string]Object {
+	vars := make(map[string]Object, len(e.store))
+	for k, v := range e.store {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,404 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Go function registered by embedder. Arity and types of arguments are taken from signature of function.
+//
+// Supported types of parameters: integer and float types, string, bool, time.Time, time.Duration, *big.Rat,
+// slices and maps with string keys of supported types, structs (from hash map, see FromGo for names of fields),
+// any (raw value, see ToGo). Result is converted by FromGo.
+// First parameter can be context.Context, it receives context of execution.
+// Function can return nothing, value, error or value and error.
+type HostFunction struct {
+	Name string
+
+	fn       reflect.Value
+	withCtx  bool
+	params   []reflect.Type // without context
+	variadic bool
+	hasValue bool
+	hasError bool
+}
+
+// returns error if fn is not a function or its signature is not supported
+func NewHostFunction(name string, fn any) (*HostFunction, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("function %s: not a function: %T", name, fn)
+	}
+
+	t := v.Type()
+	hf := &HostFunction{Name: name, fn: v, variadic: t.IsVariadic()}
+
+	for i := 0; i < t.NumIn(); i++ {
+		p := t.In(i)
+		if i == 0 && p == contextType {
+			hf.withCtx = true
+			continue
+		}
+
+		check := p
+		if hf.variadic && i == t.NumIn()-1 {
+			check = p.Elem()
+		}
+
+		if !supportedType(check, nil) {
+			return nil, fmt.Errorf("function %s: unsupported type of parameter %d: %s", name, i+1, p)
+		}
+
+		hf.params = append(hf.params, p)
+	}
+
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		if t.Out(0) == errorType {
+			hf.hasError = true
+		} else {
+			hf.hasValue = true
+		}
+	case 2:
+		if t.Out(1) != errorType {
+			return nil, fmt.Errorf("function %s: second result must be error", name)
+		}
+		hf.hasValue = true
+		hf.hasError = true
+	default:
+		return nil, fmt.Errorf("function %s: too many results", name)
+	}
+
+	if hf.hasValue && !supportedType(t.Out(0), nil) {
+		return nil, fmt.Errorf("function %s: unsupported type of result: %s", name, t.Out(0))
+	}
+
+	return hf, nil
+}
+
+// seen - structs which are being checked, for recursive types
+func supportedType(t reflect.Type, seen map[reflect.Type]bool) bool {
+	switch t {
+	case timeType, durationType, ratType:
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return supportedType(t.Elem(), seen)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && supportedType(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return true
+		}
+		if seen == nil {
+			seen = map[reflect.Type]bool{}
+		}
+		seen[t] = true
+
+		for _, f := range structFields(t) {
+			if !supportedType(t.FieldByIndex(f.index).Type, seen) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	default:
+		return false
+	}
+}
+
+// returns builtin which calls function with context ctx
+func (hf *HostFunction) Builtin(ctx context.Context) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) Object {
+			return hf.call(ctx, args)
+		},
+	}
+}
+
+func (hf *HostFunction) call(ctx context.Context, args []Object) (result Object) {
+	// panic of function or conversion of its values is error of script
+	defer func() {
+		if r := recover(); r != nil {
+			result = NewError("function %s: panic: %v", hf.Name, r)
+		}
+	}()
+
+	n := len(hf.params)
+	if hf.variadic {
+		if len(args) < n-1 {
+			return NewError("wrong number of arguments: %d want at least: %d", len(args), n-1)
+		}
+	} else if len(args) != n {
+		return NewError("wrong number of arguments: %d want: %d", len(args), n)
+	}
+
+	var in []reflect.Value
+	if hf.withCtx {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+
+	for id, arg := range args {
+		var t reflect.Type
+		if hf.variadic && id >= n-1 {
+			t = hf.params[n-1].Elem()
+		} else {
+			t = hf.params[id]
+		}
+
+		v, err := toGoValue(arg, t, objectPath{})
+		if err != nil {
+			return NewError("argument %d of %s: %s", id+1, hf.Name, err.Error())
+		}
+
+		in = append(in, v)
+	}
+
+	out := hf.fn.Call(in)
+
+	if hf.hasError {
+		if err, _ := out[len(out)-1].Interface().(error); err != nil {
+			return NewError("%s: %s", hf.Name, err.Error())
+		}
+	}
+
+	if !hf.hasValue {
+		return NULL
+	}
+
+	obj, err := fromGo(out[0])
+	if err != nil {
+		return NewError("result of %s: %s", hf.Name, err.Error())
+	}
+
+	return obj
+}
+
+// name of script type for Go type, used in errors
+func typeName(t reflect.Type) string {
+	switch t {
+	case timeType:
+		return DATETIME_OBJ
+	case durationType:
+		return DURATION_OBJ
+	case ratType:
+		return DECIMAL_OBJ
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return INTEGER_OBJ
+	case reflect.Float32, reflect.Float64:
+		return FLOAT_OBJ
+	case reflect.String:
+		return STRING_OBJ
+	case reflect.Bool:
+		return BOOLEAN_OBJ
+	case reflect.Slice:
+		return ARRAY_OBJ
+	case reflect.Map, reflect.Struct:
+		return HASH_MAP_OBJ
+	default:
+		return t.String()
+	}
+}
+
+// path - containers being converted, for cyclic values
+func toGoValue(obj Object, t reflect.Type, path objectPath) (reflect.Value, error) {
+	mismatch := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("must be %s, got: %s", typeName(t), obj.Type())
+	}
+
+	switch t {
+	case timeType:
+		d, ok := obj.(*DateTime)
+		if !ok {
+			return mismatch()
+		}
+
+		return reflect.ValueOf(d.Value), nil
+
+	case durationType:
+		d, ok := obj.(*Duration)
+		if !ok {
+			return mismatch()
+		}
+
+		return reflect.ValueOf(d.Value), nil
+
+	case ratType:
+		if obj.Type() != INTEGER_OBJ && !isDecimal(obj) {
+			return mismatch()
+		}
+
+		d, _ := toDecimal(obj)
+		return reflect.ValueOf(d.Rat()), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*Integer)
+		if !ok {
+			return mismatch()
+		}
+
+		v := reflect.New(t).Elem()
+		if v.OverflowInt(i.Value) {
+			return reflect.Value{}, fmt.Errorf("value out of range of %s: %d", t, i.Value)
+		}
+		v.SetInt(i.Value)
+		return v, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := obj.(*Integer)
+		if !ok {
+			return mismatch()
+		}
+
+		v := reflect.New(t).Elem()
+		if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+			return reflect.Value{}, fmt.Errorf("value out of range of %s: %d", t, i.Value)
+		}
+		v.SetUint(uint64(i.Value))
+		return v, nil
+
+	case reflect.Float32, reflect.Float64:
+		if !isNumber(obj) {
+			return mismatch()
+		}
+
+		v := reflect.New(t).Elem()
+		v.SetFloat(toFloat(obj))
+		return v, nil
+
+	case reflect.String:
+		s, ok := obj.(*String)
+		if !ok {
+			return mismatch()
+		}
+
+		return reflect.ValueOf(s.Value).Convert(t), nil
+
+	case reflect.Bool:
+		b, ok := obj.(*Boolean)
+		if !ok {
+			return mismatch()
+		}
+
+		return reflect.ValueOf(b.Value).Convert(t), nil
+
+	case reflect.Slice:
+		a, ok := obj.(*Array)
+		if !ok {
+			return mismatch()
+		}
+
+		if !path.enter(a) {
+			return reflect.Value{}, errors.New("cyclic value")
+		}
+		defer path.leave(a)
+
+		v := reflect.MakeSlice(t, len(a.Elements), len(a.Elements))
+		for id, el := range a.Elements {
+			ev, err := toGoValue(el, t.Elem(), path)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", id, err)
+			}
+			v.Index(id).Set(ev)
+		}
+
+		return v, nil
+
+	case reflect.Map:
+		h, ok := obj.(*HashMap)
+		if !ok {
+			return mismatch()
+		}
+
+		if !path.enter(h) {
+			return reflect.Value{}, errors.New("cyclic value")
+		}
+		defer path.leave(h)
+
+		v := reflect.MakeMapWithSize(t, len(h.Pairs))
+		for _, pair := range h.Ordered() {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("key %s must be STRING, got: %s", pair.Key.ToString(), pair.Key.Type())
+			}
+
+			ev, err := toGoValue(pair.Value, t.Elem(), path)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %s: %w", key.Value, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(key.Value).Convert(t.Key()), ev)
+		}
+
+		return v, nil
+
+	case reflect.Struct:
+		h, ok := obj.(*HashMap)
+		if !ok {
+			return mismatch()
+		}
+
+		if !path.enter(h) {
+			return reflect.Value{}, errors.New("cyclic value")
+		}
+		defer path.leave(h)
+
+		// keys without fields are ignored, fields without keys have zero value
+		v := reflect.New(t).Elem()
+		for _, f := range structFields(t) {
+			pair, ok := h.Pairs[(&String{Value: f.name}).HashKey()]
+			if !ok {
+				continue
+			}
+
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				// field of nil embedded pointer
+				continue
+			}
+
+			ev, err := toGoValue(pair.Value, fv.Type(), path)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			fv.Set(ev)
+		}
+
+		return v, nil
+
+	case reflect.Interface:
+		if obj == NULL {
+			return reflect.Zero(t), nil
+		}
+
+		raw, err := toGo(obj, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&raw).Elem(), nil
+
+	default:
+		return mismatch()
+	}
+}
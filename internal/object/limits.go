@@ -0,0 +1,136 @@
+package object
+
+import "fmt"
+
+const (
+	STEPS_LIMIT      = "STEPS"
+	CALL_DEPTH_LIMIT = "CALL_DEPTH"
+	ALLOC_SIZE_LIMIT = "ALLOC_SIZE"
+	TIMEOUT_LIMIT    = "TIMEOUT"
+)
+
+// Limits of script execution. Zero value of field means no limit, except MaxCallDepth:
+// zero is DefaultMaxCallDepth, negative value disables the check.
+type Limits struct {
+	// maximum number of steps: evaluated AST nodes in evaluator, executed instructions in VM,
+	// the same script takes different number of steps in backends
+	MaxSteps      int
+	MaxCallDepth  int  // maximum depth of function calls
+	MaxAllocSize  int  // maximum length of string (in bytes), array or hash map
+	CheckOverflow bool // integer overflow in operators is error, otherwise value wraps around
+}
+
+// call depth if Limits.MaxCallDepth is zero, infinite recursion must not overflow Go stack
+const DefaultMaxCallDepth = 1000
+
+// maximum depth of function calls, 0 if check is disabled
+func (l Limits) CallDepth() int {
+	switch {
+	case l.MaxCallDepth == 0:
+		return DefaultMaxCallDepth
+	case l.MaxCallDepth < 0:
+		return 0
+	}
+
+	return l.MaxCallDepth
+}
+
+// applies operator with overflow check if it is enabled
+func (l Limits) PrefixOp(op string, right Object) Object {
+	if l.CheckOverflow {
+		return CheckedPrefixOp(op, right)
+	}
+
+	return PrefixOp(op, right)
+}
+
+// applies operator with overflow check if it is enabled
+func (l Limits) InfixOp(op string, left, right Object) Object {
+	if l.CheckOverflow {
+		return CheckedInfixOp(op, left, right)
+	}
+
+	return InfixOp(op, left, right)
+}
+
+type LimitError struct {
+	Limit string // STEPS_LIMIT, CALL_DEPTH_LIMIT, ALLOC_SIZE_LIMIT, TIMEOUT_LIMIT
+	Max   int
+	Err   error // context error for TIMEOUT_LIMIT
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case STEPS_LIMIT:
+		return fmt.Sprintf("steps limit exceeded: %d", e.Max)
+	case CALL_DEPTH_LIMIT:
+		return fmt.Sprintf("call depth limit exceeded: %d", e.Max)
+	case ALLOC_SIZE_LIMIT:
+		return fmt.Sprintf("allocation size limit exceeded: %d", e.Max)
+	case TIMEOUT_LIMIT:
+		return fmt.Sprintf("execution timeout: %v", e.Err)
+	default:
+		return "limit exceeded: " + e.Limit
+	}
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// returns error if length of string, array or hash map exceeds MaxAllocSize, otherwise nil
+func (l Limits) CheckAlloc(obj Object) *LimitError {
+	var size int
+	switch obj := obj.(type) {
+	case *String:
+		size = len(obj.Value)
+	case *Array:
+		size = len(obj.Elements)
+	case *HashMap:
+		size = len(obj.Pairs)
+	}
+
+	return l.checkSize(int64(size))
+}
+
+// as CheckAlloc, but for projected result of operator, called before result is allocated
+func (l Limits) CheckInfixAlloc(op string, left, right Object) *LimitError {
+	ls, lok := left.(*String)
+	rs, rok := right.(*String)
+	if op != "+" || !lok || !rok {
+		return nil
+	}
+
+	return l.checkSize(int64(len(ls.Value)) + int64(len(rs.Value)))
+}
+
+// as CheckAlloc, but for projected result of template literal, called before string is built
+func (l Limits) CheckTemplateAlloc(parts []Object) *LimitError {
+	if l.MaxAllocSize <= 0 {
+		return nil
+	}
+
+	size := int64(0)
+	for _, part := range parts {
+		size += textSize(part)
+	}
+
+	return l.checkSize(size)
+}
+
+// as CheckAlloc, but for projected result of builtin (see Builtin.Size), called before builtin
+func (l Limits) CheckCallAlloc(fn *Builtin, args []Object) *LimitError {
+	if fn.Size == nil || l.MaxAllocSize <= 0 {
+		return nil
+	}
+
+	return l.checkSize(fn.Size(args...))
+}
+
+func (l Limits) checkSize(size int64) *LimitError {
+	if l.MaxAllocSize > 0 && size > int64(l.MaxAllocSize) {
+		return &LimitError{Limit: ALLOC_SIZE_LIMIT, Max: l.MaxAllocSize}
+	}
+
+	return nil
+}
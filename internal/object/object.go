@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/botscubes/bql/internal/ast"
+	"github.com/botscubes/bql/internal/token"
 )
 
 type ObjectType string
 
 type BuiltinFunction func(args ...Object) Object
 
+// calls function (function, closure or builtin) with arguments, provided by evaluator and VM
+// to builtins which call functions passed as arguments
+type Caller func(fn Object, args ...Object) Object
+
 type Object interface {
 	Type() ObjectType
 	ToString() string
@@ -23,15 +32,23 @@ const (
 	NULL_OBJ  = "NULL"
 
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 
 	INTEGER_OBJ  = "INTEGER"
+	FLOAT_OBJ    = "FLOAT"
+	DECIMAL_OBJ  = "DECIMAL"
 	BOOLEAN_OBJ  = "BOOLEAN"
 	STRING_OBJ   = "STRING"
 	ARRAY_OBJ    = "ARRAY"
 	HASH_MAP_OBJ = "HASH_MAP"
+	DATETIME_OBJ = "DATETIME"
+	DURATION_OBJ = "DURATION"
 
 	FUNCTION_OBJ = "FUNCTION"
 	BUILTIN_OBJ  = "BUILTIN"
+
+	BLOCK_SCOPE_OBJ = "BLOCK_SCOPE"
 )
 
 type HashKey struct {
@@ -50,6 +67,7 @@ func (n *Null) ToString() string { return "Null" }
 
 type Error struct {
 	Message string
+	Pos     token.Pos // position of node where error occurred, Line == 0 - unknown
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
@@ -62,6 +80,16 @@ type Return struct {
 func (r *Return) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (r *Return) ToString() string { return r.Value.ToString() }
 
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) ToString() string { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) ToString() string { return "continue" }
+
 type Integer struct {
 	Value int64
 }
@@ -70,6 +98,60 @@ func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) ToString() string { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) HashKey() HashKey { return HashKey{Type: i.Type(), Value: uint64(i.Value)} }
 
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) ToString() string { return strconv.FormatFloat(f.Value, 'g', -1, 64) }
+
+// whole numbers have keys of integers, so 1.0 and 1 are the same key of hash map
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && math.Abs(f.Value) < math.MaxInt64 {
+		return (&Integer{Value: int64(f.Value)}).HashKey()
+	}
+
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// exact decimal number Value * 10^-Scale, Scale >= 0.
+// Scale is kept in results of operations: 19.90d + 1d = 20.90d
+type Decimal struct {
+	Value *big.Int
+	Scale int32
+}
+
+func (d *Decimal) Type() ObjectType { return DECIMAL_OBJ }
+func (d *Decimal) ToString() string { return d.String() }
+
+// numbers which differ in trailing zeros (1.5 and 1.50) have equal keys, whole numbers have keys of integers
+func (d *Decimal) HashKey() HashKey {
+	n := d.normalize()
+	if n.Scale == 0 && n.Value.IsInt64() {
+		return (&Integer{Value: n.Value.Int64()}).HashKey()
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(n.String()))
+
+	return HashKey{Type: d.Type(), Value: h.Sum64()}
+}
+
+// moment of time with timezone
+type DateTime struct {
+	Value time.Time
+}
+
+func (d *DateTime) Type() ObjectType { return DATETIME_OBJ }
+func (d *DateTime) ToString() string { return d.Value.Format(time.RFC3339) }
+
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+func (d *Duration) ToString() string { return d.Value.String() }
+
 type Boolean struct {
 	Value bool
 }
@@ -109,6 +191,79 @@ func (f *Function) ToString() string {
 	return out.String()
 }
 
+// position in source code of instruction starting at Offset
+type Position struct {
+	Offset int
+	Pos    token.Pos
+}
+
+// function compiled to bytecode (see internal/compiler)
+type CompiledFunction struct {
+	Instructions  []byte
+	Positions     []Position // sorted by Offset
+	NumLocals     int
+	NumParameters int
+	LocalNames    []string // names of local variables by index
+	Source        string
+}
+
+func (f *CompiledFunction) Type() ObjectType { return FUNCTION_OBJ }
+func (f *CompiledFunction) ToString() string { return f.Source }
+
+// returns source position of instruction at offset ip
+func (f *CompiledFunction) PosAt(ip int) token.Pos {
+	var pos token.Pos
+	for _, p := range f.Positions {
+		if p.Offset > ip {
+			break
+		}
+		pos = p.Pos
+	}
+
+	return pos
+}
+
+// local variables of function call or of block
+type Scope struct {
+	Vars   []Object
+	Names  []string
+	Outer  *Scope
+	Consts []bool // variables declared by const, nil if there are no constants
+}
+
+// marks variable id as constant (const) or as variable (let)
+func (s *Scope) Declare(id int, constant bool) {
+	if s.Consts == nil {
+		if !constant {
+			return
+		}
+		s.Consts = make([]bool, len(s.Vars))
+	}
+
+	s.Consts[id] = constant
+}
+
+// returns true if variable id is declared by const
+func (s *Scope) IsConst(id int) bool {
+	return s.Consts != nil && s.Consts[id]
+}
+
+// variables declared in block (let, const, variables of for loop), each execution of block creates new Scope
+type BlockScope struct {
+	Names []string
+}
+
+func (bs *BlockScope) Type() ObjectType { return BLOCK_SCOPE_OBJ }
+func (bs *BlockScope) ToString() string { return "block scope" }
+
+type Closure struct {
+	Fn    *CompiledFunction
+	Scope *Scope // scope where function was created, nil - global
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) ToString() string { return c.Fn.ToString() }
+
 type String struct {
 	Value string
 }
@@ -128,18 +283,7 @@ type Array struct {
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
 func (a *Array) ToString() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, el := range a.Elements {
-		elements = append(elements, el.ToString())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
+	return containerString(a, objectPath{})
 }
 
 type HashPair struct {
@@ -147,28 +291,127 @@ type HashPair struct {
 	Value Object
 }
 
+// hash map keeps insertion order of keys, pairs must be changed by Set and Delete
 type HashMap struct {
 	Pairs map[HashKey]HashPair
+	keys  []HashKey // in insertion order
+}
+
+func NewHashMap(size int) *HashMap {
+	return &HashMap{
+		Pairs: make(map[HashKey]HashPair, size),
+		keys:  make([]HashKey, 0, size),
+	}
+}
+
+// adds pair to the end or replaces value of existing key (its position is kept), key must be Hashable
+func (h *HashMap) Set(key, value Object) {
+	hashKey := key.(Hashable).HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.keys = append(h.keys, hashKey)
+	}
+
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// removes pair, returns false if there is no key
+func (h *HashMap) Delete(key HashKey) bool {
+	if _, ok := h.Pairs[key]; !ok {
+		return false
+	}
+
+	delete(h.Pairs, key)
+	for id, k := range h.keys {
+		if k == key {
+			h.keys = append(h.keys[:id], h.keys[id+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// pairs in insertion order
+func (h *HashMap) Ordered() []HashPair {
+	pairs := make([]HashPair, len(h.keys))
+	for id, key := range h.keys {
+		pairs[id] = h.Pairs[key]
+	}
+
+	return pairs
 }
 
 func (h *HashMap) Type() ObjectType { return HASH_MAP_OBJ }
 func (h *HashMap) ToString() string {
-	var out bytes.Buffer
+	return containerString(h, objectPath{})
+}
 
-	pairs := []string{}
-	for _, el := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", el.Key.ToString(), el.Value.ToString()))
+// arrays and hash maps on path from root value to current one.
+// Container can be stored in itself (m.self = m), such value is cyclic
+type objectPath map[Object]bool
+
+// adds container to path, returns false if it is already there
+func (p objectPath) enter(obj Object) bool {
+	if p[obj] {
+		return false
 	}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("]")
+	p[obj] = true
+	return true
+}
 
-	return out.String()
+func (p objectPath) leave(obj Object) {
+	delete(p, obj)
+}
+
+// string of array or hash map, container which contains itself is printed as [...]
+func containerString(obj Object, path objectPath) string {
+	switch obj := obj.(type) {
+	case *Array:
+		if !path.enter(obj) {
+			return "[...]"
+		}
+		defer path.leave(obj)
+
+		elements := []string{}
+		for _, el := range obj.Elements {
+			elements = append(elements, containerString(el, path))
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *HashMap:
+		if !path.enter(obj) {
+			return "[...]"
+		}
+		defer path.leave(obj)
+
+		pairs := []string{}
+		for _, el := range obj.Ordered() {
+			pairs = append(pairs, fmt.Sprintf("%s: %s", el.Key.ToString(), containerString(el.Value, path)))
+		}
+
+		return "[" + strings.Join(pairs, ", ") + "]"
+	default:
+		return obj.ToString()
+	}
 }
 
 type Builtin struct {
 	Fn BuiltinFunction
+	// used instead of Fn if set, for builtins which call functions (map, filter ...)
+	CallerFn func(call Caller, args ...Object) Object
+	// projected length of result if set, checked against Limits.MaxAllocSize before call,
+	// for builtins which build large value from small arguments (repeat, range ...)
+	Size func(args ...Object) int64
+}
+
+// calls builtin, call is used by builtins which call functions
+func (b *Builtin) Call(call Caller, args ...Object) Object {
+	if b.CallerFn != nil {
+		return b.CallerFn(call, args...)
+	}
+
+	return b.Fn(args...)
 }
 
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
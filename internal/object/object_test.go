@@ -0,0 +1,321 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type product struct {
+	*base
+	Name   string   `bql:"name"`
+	Price  *big.Rat `json:"price,omitempty"`
+	Tags   []string
+	Skip   bool `bql:"-"`
+	hidden int
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+func TestFromGo(t *testing.T) {
+	shared := []int{1}
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "Null"},
+		{(*int)(nil), "Null"},
+		{[]int(nil), "Null"},
+		{uint64(math.MaxInt64), "9223372036854775807"},
+		{int8(-5), "-5"},
+		{float32(1.5), "1.5"},
+		{big.NewRat(1, 4), "0.25"},
+		{[]any{"a", true, nil}, `[a, true, Null]`},
+		{map[string]int{"b": 2, "a": 1}, "[a: 1, b: 2]"},
+		{map[int]string{10: "x", -1: "y"}, "[-1: y, 10: x]"},
+		// the same value twice is not cyclic
+		{[]any{shared, shared}, "[[1], [1]]"},
+		// tags and embedded struct, fields of nil embedded pointer are skipped
+		{product{Name: "tea", Tags: []string{"hot"}, Skip: true, hidden: 1}, "[name: tea, price: Null, Tags: [hot]]"},
+		{product{base: &base{ID: 7}, Name: "tea"}, "[id: 7, name: tea, price: Null, Tags: Null]"},
+		{&node{Value: 1, Next: &node{Value: 2}}, "[Value: 1, Next: [Value: 2, Next: Null]]"},
+	}
+
+	for _, test := range tests {
+		obj, err := FromGo(test.input)
+		if err != nil {
+			t.Errorf("unexpected error: %v for value: %#v", err, test.input)
+			continue
+		}
+
+		if obj.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s for value: %#v", obj.ToString(), test.expected, test.input)
+		}
+	}
+}
+
+func TestFromGoErrors(t *testing.T) {
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = cyclicMap
+
+	cyclicSlice := []any{nil}
+	cyclicSlice[0] = cyclicSlice
+
+	cyclicNode := &node{}
+	cyclicNode.Next = cyclicNode
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{uint64(math.MaxInt64) + 1, "value out of range of INTEGER: 9223372036854775808"},
+		{[]uint{math.MaxUint64}, "element 0: value out of range of INTEGER: 18446744073709551615"},
+		{cyclicMap, "key self: cyclic value"},
+		{cyclicSlice, "element 0: cyclic value"},
+		{cyclicNode, "field Next: cyclic value"},
+		{big.NewRat(1, 3), "no finite decimal representation: 1/3"},
+		{map[float64]int{1.5: 1}, "unsupported type of map key: float64"},
+		{make(chan int), "unsupported type: chan int"},
+	}
+
+	for _, test := range tests {
+		_, err := FromGo(test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("wrong error. got: %v expected: %s for value of type: %T", err, test.expected, test.input)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	hashMap := NewHashMap(2)
+	hashMap.Set(&Integer{Value: 1}, &String{Value: "a"})
+	hashMap.Set(&Decimal{Value: big.NewInt(150), Scale: 2}, NULL)
+
+	tests := []struct {
+		input    Object
+		expected any
+	}{
+		{&Integer{Value: -1}, int64(-1)},
+		{&Float{Value: 0.5}, 0.5},
+		{NULL, nil},
+		{&Array{Elements: []Object{TRUE, &String{Value: "x"}}}, []any{true, "x"}},
+		{hashMap, map[string]any{"1": "a", "1.50": nil}},
+	}
+
+	for _, test := range tests {
+		v, err := ToGo(test.input)
+		if err != nil {
+			t.Errorf("unexpected error: %v for object: %s", err, test.input.ToString())
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("wrong result. got: %#v expected: %#v", v, test.expected)
+		}
+	}
+
+	v, err := ToGo(&Decimal{Value: big.NewInt(1999), Scale: 2})
+	if r, ok := v.(*big.Rat); err != nil || !ok || r.Cmp(big.NewRat(1999, 100)) != 0 {
+		t.Errorf("wrong decimal: %v, %v", v, err)
+	}
+}
+
+func TestToGoErrors(t *testing.T) {
+	colliding := NewHashMap(2)
+	colliding.Set(&Integer{Value: 1}, &String{Value: "a"})
+	colliding.Set(&String{Value: "1"}, &String{Value: "b"})
+
+	cyclicArray := &Array{}
+	cyclicArray.Elements = []Object{cyclicArray}
+
+	cyclicMap := NewHashMap(1)
+	cyclicMap.Set(&String{Value: "self"}, &Array{Elements: []Object{cyclicMap}})
+
+	tests := []struct {
+		input    Object
+		expected string
+	}{
+		{colliding, "duplicate key: 1"},
+		{cyclicArray, "cyclic value"},
+		{cyclicMap, "cyclic value"},
+		{NewError("boom"), "error: boom"},
+		{&Builtin{}, "unsupported result type: BUILTIN"},
+	}
+
+	for _, test := range tests {
+		_, err := ToGo(test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("wrong error. got: %v expected: %s", err, test.expected)
+		}
+	}
+
+	// the same container twice is not cyclic
+	shared := &Array{Elements: []Object{&Integer{Value: 1}}}
+	if _, err := ToGo(&Array{Elements: []Object{shared, shared}}); err != nil {
+		t.Errorf("unexpected error for shared array: %v", err)
+	}
+}
+
+func TestHostFunction(t *testing.T) {
+	funcs := map[string]any{
+		"small":   func(x uint8) uint8 { return x },
+		"big":     func() uint64 { return math.MaxUint64 },
+		"keys":    func(m map[string]int) int { return len(m) },
+		"price":   func(p product) string { return p.Name + " " + p.Price.FloatString(2) },
+		"id":      func(p product) bool { return p.base == nil },
+		"ratio":   func(r *big.Rat) string { return r.FloatString(3) },
+		"explode": func(s string) string { panic("bad " + s) },
+		"raw":     func(v any) any { return v },
+		"fail":    func() (int, error) { return 0, errors.New("boom") },
+	}
+
+	product := NewHashMap(3)
+	product.Set(&String{Value: "name"}, &String{Value: "tea"})
+	product.Set(&String{Value: "price"}, &Decimal{Value: big.NewInt(199), Scale: 2})
+	product.Set(&String{Value: "id"}, &Integer{Value: 5})
+
+	intKeys := NewHashMap(1)
+	intKeys.Set(&Integer{Value: 1}, &Integer{Value: 1})
+
+	cyclic := &Array{}
+	cyclic.Elements = []Object{cyclic}
+
+	tests := []struct {
+		name     string
+		args     []Object
+		expected string
+	}{
+		{"small", []Object{&Integer{Value: 255}}, "255"},
+		{"small", []Object{&Integer{Value: 256}}, "error: argument 1 of small: value out of range of uint8: 256"},
+		{"small", []Object{&Integer{Value: -1}}, "error: argument 1 of small: value out of range of uint8: -1"},
+		{"big", nil, "error: result of big: value out of range of INTEGER: 18446744073709551615"},
+		{"keys", []Object{intKeys}, "error: argument 1 of keys: key 1 must be STRING, got: INTEGER"},
+		{"price", []Object{product}, "tea 1.99"},
+		// field of nil embedded pointer is not set
+		{"id", []Object{product}, "true"},
+		{"ratio", []Object{&Integer{Value: 2}}, "2.000"},
+		{"ratio", []Object{&Float{Value: 0.5}}, "error: argument 1 of ratio: must be DECIMAL, got: FLOAT"},
+		{"explode", []Object{&String{Value: "input"}}, "error: function explode: panic: bad input"},
+		{"raw", []Object{cyclic}, "error: argument 1 of raw: cyclic value"},
+		{"fail", nil, "error: fail: boom"},
+	}
+
+	for _, test := range tests {
+		hf, err := NewHostFunction(test.name, funcs[test.name])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		result := hf.Builtin(context.Background()).Fn(test.args...)
+		if result.ToString() != test.expected {
+			t.Errorf("wrong result of %s. got: %s expected: %s", test.name, result.ToString(), test.expected)
+		}
+	}
+}
+
+func TestNewHostFunctionErrors(t *testing.T) {
+	tests := []struct {
+		fn       any
+		expected string
+	}{
+		{42, "not a function: int"},
+		{func(m map[int]string) {}, "unsupported type of parameter 1: map[int]string"},
+		{func(c chan int) {}, "unsupported type of parameter 1: chan int"},
+		{func() (int, int) { return 0, 0 }, "second result must be error"},
+		{func() (int, int, error) { return 0, 0, nil }, "too many results"},
+	}
+
+	for _, test := range tests {
+		_, err := NewHostFunction("f", test.fn)
+		if err == nil || !strings.HasSuffix(err.Error(), test.expected) {
+			t.Errorf("wrong error. got: %v expected: %s", err, test.expected)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"19.99", "19.99"},
+		{" -5 ", "-5"},
+		{"1.5e3", "1500"},
+		{"1e-2", "0.01"},
+		{".5", "0.5"},
+		{"+0.10", "0.10"},
+		{"abc", `error: invalid decimal: "abc"`},
+		{"1.2.3", `error: invalid decimal: "1.2.3"`},
+		{"--1", `error: invalid decimal: "--1"`},
+		{"1e-101", `error: invalid decimal: "1e-101"`},
+		{"0." + strings.Repeat("1", 101), "error: too many digits after point: \"0." + strings.Repeat("1", 101) + "\""},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDecimal(test.input)
+
+		got := ""
+		if err != nil {
+			got = "error: " + err.Error()
+		} else {
+			got = d.String()
+		}
+
+		if got != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s for input: %q", got, test.expected, test.input)
+		}
+	}
+}
+
+func TestDecimalOperators(t *testing.T) {
+	dec := func(s string) *Decimal {
+		d, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("invalid decimal: %s", s)
+		}
+		return d
+	}
+
+	tests := []struct {
+		op          string
+		left, right Object
+		expected    string
+	}{
+		{"/", dec("10.00"), &Integer{Value: 4}, "2.50"},
+		{"/", dec("1"), &Integer{Value: 3}, "0.3333333333333333"},
+		// result of division is rounded to maxDecimalScale digits
+		{"/", dec("2e-100"), dec("3"), "0." + strings.Repeat("0", 99) + "1"},
+		{"/", dec("1"), dec("0"), "error: division by zero"},
+		{"*", dec("1e-60"), dec("1e-60"), "error: decimal precision exceeded: 120 digits after point"},
+		{"==", dec("1.50"), &Float{Value: 1.5}, "true"},
+		{"==", &Float{Value: 0.1}, dec("0.1"), "false"},
+		{"!=", dec("1"), &Float{Value: math.NaN()}, "true"},
+		{"<", dec("1"), &Float{Value: 2}, "error: type mismatch: DECIMAL < FLOAT"},
+		{"+", dec("0.1"), &String{Value: "a"}, "error: type mismatch: DECIMAL + STRING"},
+	}
+
+	for _, test := range tests {
+		result := InfixOp(test.op, test.left, test.right)
+		if result.ToString() != test.expected {
+			t.Errorf("wrong result. got: %s expected: %s for: %s %s %s",
+				result.ToString(), test.expected, test.left.ToString(), test.op, test.right.ToString())
+		}
+	}
+
+	// keys of hash map: trailing zeros and whole numbers
+	if dec("1.50").HashKey() != dec("1.5").HashKey() || dec("2.00").HashKey() != (&Integer{Value: 2}).HashKey() {
+		t.Errorf("keys of equal decimals differ")
+	}
+}
@@ -0,0 +1,441 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
+func NewError(formating string, parameters ...any) *Error {
+	return &Error{Message: fmt.Sprintf(formating, parameters...)}
+}
+
+func isError(obj Object) bool {
+	if obj != nil {
+		return obj.Type() == ERROR_OBJ
+	}
+	return false
+}
+
+func boolToBooleanObj(b bool) *Boolean {
+	if b {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func PrefixOp(op string, right Object) Object {
+	switch op {
+	case "!":
+		return evalExclOpExpr(right)
+	case "-":
+		return evalMinusPrefixOpExpr(right)
+	default:
+		return NewError("unknown operator: %s", op)
+	}
+}
+
+func InfixOp(op string, left Object, right Object) Object {
+	switch {
+	case left.Type() == INTEGER_OBJ && right.Type() == INTEGER_OBJ:
+		return evalIntInfixExpr(op, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpr(op, left, right)
+	case (left == NULL || right == NULL) && (op == "==" || op == "!="):
+		return boolToBooleanObj((left == right) == (op == "=="))
+	case isTime(left) || isTime(right):
+		return evalTimeInfixExpr(op, left, right)
+	case isDecimal(left) || isDecimal(right):
+		return evalDecimalInfixExpr(op, left, right)
+	case left.Type() != right.Type():
+		return NewError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	case left.Type() == STRING_OBJ && right.Type() == STRING_OBJ:
+		return evalStringInfixExpr(op, left, right)
+	case op == "==":
+		return boolToBooleanObj(left == right)
+	case op == "!=":
+		return boolToBooleanObj(left != right)
+	case op == "||" && left.Type() == BOOLEAN_OBJ:
+		return boolToBooleanObj(left.(*Boolean).Value || right.(*Boolean).Value)
+	case op == "&&" && left.Type() == BOOLEAN_OBJ:
+		return boolToBooleanObj(left.(*Boolean).Value && right.(*Boolean).Value)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// as PrefixOp, but overflow of integer is error instead of wrapping around (see Limits.CheckOverflow)
+func CheckedPrefixOp(op string, right Object) Object {
+	if r, ok := right.(*Integer); ok && op == "-" && r.Value == math.MinInt64 {
+		return NewError("integer overflow: -(%d)", r.Value)
+	}
+
+	return PrefixOp(op, right)
+}
+
+// as InfixOp, but overflow of integer is error instead of wrapping around (see Limits.CheckOverflow)
+func CheckedInfixOp(op string, left Object, right Object) Object {
+	l, lok := left.(*Integer)
+	r, rok := right.(*Integer)
+	if lok && rok && overflows(op, l.Value, r.Value) {
+		return NewError("integer overflow: %d %s %d", l.Value, op, r.Value)
+	}
+
+	return InfixOp(op, left, right)
+}
+
+// returns true if result of integer operation doesn't fit into int64
+func overflows(op string, a, b int64) bool {
+	switch op {
+	case "+":
+		return b > 0 && a > math.MaxInt64-b || b < 0 && a < math.MinInt64-b
+	case "-":
+		return b < 0 && a > math.MaxInt64+b || b > 0 && a < math.MinInt64+b
+	case "*":
+		if a == 0 || b == 0 {
+			return false
+		}
+		return (a*b)/b != a || a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64
+	case "/":
+		return a == math.MinInt64 && b == -1
+	default:
+		return false
+	}
+}
+
+func evalExclOpExpr(right Object) Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+func evalMinusPrefixOpExpr(right Object) Object {
+	switch right := right.(type) {
+	case *Integer:
+		return &Integer{Value: -right.Value}
+	case *Float:
+		return &Float{Value: -right.Value}
+	case *Decimal:
+		return &Decimal{Value: new(big.Int).Neg(right.Value), Scale: right.Scale}
+	default:
+		return NewError("unknown operator: -%s", right.Type())
+	}
+}
+
+func isNumber(obj Object) bool {
+	return obj.Type() == INTEGER_OBJ || obj.Type() == FLOAT_OBJ
+}
+
+// converts INTEGER or FLOAT object to float64
+func toFloat(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value)
+	case *Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+func evalIntInfixExpr(op string, left Object, right Object) Object {
+	lVal := left.(*Integer).Value
+	rVal := right.(*Integer).Value
+	switch op {
+	case "+":
+		return &Integer{Value: lVal + rVal}
+	case "-":
+		return &Integer{Value: lVal - rVal}
+	case "*":
+		return &Integer{Value: lVal * rVal}
+	case "/":
+		if rVal == 0 {
+			return NewError("division by zero")
+		}
+		return &Integer{Value: lVal / rVal}
+	case "%":
+		if rVal == 0 {
+			return NewError("modulo by zero")
+		}
+		return &Integer{Value: lVal % rVal}
+	case "==":
+		return boolToBooleanObj(lVal == rVal)
+	case "!=":
+		return boolToBooleanObj(lVal != rVal)
+	case "<":
+		return boolToBooleanObj(lVal < rVal)
+	case ">":
+		return boolToBooleanObj(lVal > rVal)
+	case "<=":
+		return boolToBooleanObj(lVal <= rVal)
+	case ">=":
+		return boolToBooleanObj(lVal >= rVal)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+// if at least one operand is FLOAT, INTEGER operand is converted to FLOAT
+func evalFloatInfixExpr(op string, left Object, right Object) Object {
+	lVal := toFloat(left)
+	rVal := toFloat(right)
+	switch op {
+	case "+":
+		return &Float{Value: lVal + rVal}
+	case "-":
+		return &Float{Value: lVal - rVal}
+	case "*":
+		return &Float{Value: lVal * rVal}
+	case "/":
+		if rVal == 0 {
+			return NewError("division by zero")
+		}
+		return &Float{Value: lVal / rVal}
+	case "%":
+		if rVal == 0 {
+			return NewError("modulo by zero")
+		}
+		return &Float{Value: math.Mod(lVal, rVal)}
+	case "==":
+		return boolToBooleanObj(lVal == rVal)
+	case "!=":
+		return boolToBooleanObj(lVal != rVal)
+	case "<":
+		return boolToBooleanObj(lVal < rVal)
+	case ">":
+		return boolToBooleanObj(lVal > rVal)
+	case "<=":
+		return boolToBooleanObj(lVal <= rVal)
+	case ">=":
+		return boolToBooleanObj(lVal >= rVal)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func evalStringInfixExpr(op string, left Object, right Object) Object {
+	lVal := left.(*String).Value
+	rVal := right.(*String).Value
+	switch op {
+	case "+":
+		return &String{Value: lVal + rVal}
+	case "==":
+		return boolToBooleanObj(lVal == rVal)
+	case "!=":
+		return boolToBooleanObj(lVal != rVal)
+	case ">":
+		return boolToBooleanObj(lVal > rVal)
+	case "<":
+		return boolToBooleanObj(lVal < rVal)
+	case ">=":
+		return boolToBooleanObj(lVal >= rVal)
+	case "<=":
+		return boolToBooleanObj(lVal <= rVal)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func IndexOp(left, index Object) Object {
+	switch {
+	case left.Type() == ARRAY_OBJ && index.Type() == INTEGER_OBJ:
+		return evalArrayIndexExp(left, index)
+	case left.Type() == STRING_OBJ && index.Type() == INTEGER_OBJ:
+		return evalStringIndexExp(left, index)
+	case left.Type() == HASH_MAP_OBJ:
+		return evalHashMapIndexExp(left, index)
+	default:
+		return NewError("index operator not supported: %s", left.Type())
+	}
+}
+
+// m.name, the same as m["name"] for hash map, for DATETIME and DURATION returns their parts (t.hour)
+func MemberOp(left Object, name string) Object {
+	switch left := left.(type) {
+	case *HashMap:
+		return evalHashMapIndexExp(left, &String{Value: name})
+	case *DateTime:
+		return dateTimeMember(left.Value, name)
+	case *Duration:
+		return durationMember(left.Value, name)
+	default:
+		return NewError("member access not supported: %s", left.Type())
+	}
+}
+
+// m.name = value, returns error or nil
+func SetMemberOp(left Object, name string, value Object) Object {
+	hashMap, ok := left.(*HashMap)
+	if !ok {
+		return NewError("member access not supported: %s", left.Type())
+	}
+
+	hashMap.Set(&String{Value: name}, value)
+
+	return nil
+}
+
+// a[i] = value or m[key] = value, returns error or nil
+func SetIndexOp(left, index, value Object) Object {
+	switch left := left.(type) {
+	case *Array:
+		i, ok := index.(*Integer)
+		if !ok {
+			return NewError("array index must be INTEGER, got: %s", index.Type())
+		}
+
+		if i.Value < 0 || i.Value >= int64(len(left.Elements)) {
+			return NewError("index out of range: %d, length: %d", i.Value, len(left.Elements))
+		}
+
+		left.Elements[i.Value] = value
+
+	case *HashMap:
+		if _, ok := index.(Hashable); !ok {
+			return NewError("unusable as hash key: %s", index.Type())
+		}
+
+		left.Set(index, value)
+
+	default:
+		return NewError("index assignment not supported: %s", left.Type())
+	}
+
+	return nil
+}
+
+// concatenation of parts of template, values are converted by ToString
+func TemplateOp(parts []Object) Object {
+	var out strings.Builder
+	for _, part := range parts {
+		out.WriteString(toText(part))
+	}
+
+	return &String{Value: out.String()}
+}
+
+// value of string or ToString of other objects
+func toText(obj Object) string {
+	if s, ok := obj.(*String); ok {
+		return s.Value
+	}
+
+	return obj.ToString()
+}
+
+// a[start:end] or s[start:end], null bound is start or end of value.
+// Bounds are clamped to length, string is sliced by characters
+func SliceOp(left, start, end Object) Object {
+	var length int
+	switch left := left.(type) {
+	case *Array:
+		length = len(left.Elements)
+	case *String:
+		length = utf8.RuneCountInString(left.Value)
+	default:
+		return NewError("slice operator not supported: %s", left.Type())
+	}
+
+	from, err := sliceBound(start, 0, length)
+	if err != nil {
+		return err
+	}
+
+	to, err := sliceBound(end, length, length)
+	if err != nil {
+		return err
+	}
+
+	if from > to {
+		from = to
+	}
+
+	if a, ok := left.(*Array); ok {
+		elements := make([]Object, to-from)
+		copy(elements, a.Elements[from:to])
+		return &Array{Elements: elements}
+	}
+
+	runes := []rune(left.(*String).Value)
+	return &String{Value: string(runes[from:to])}
+}
+
+func sliceBound(bound Object, def int, length int) (int, Object) {
+	if bound == NULL {
+		return def, nil
+	}
+
+	i, ok := bound.(*Integer)
+	if !ok {
+		return 0, NewError("slice index must be INTEGER, got: %s", bound.Type())
+	}
+
+	switch {
+	case i.Value < 0:
+		return 0, nil
+	case i.Value > int64(length):
+		return length, nil
+	default:
+		return int(i.Value), nil
+	}
+}
+
+func evalArrayIndexExp(left, index Object) Object {
+	array := left.(*Array)
+	idx := index.(*Integer).Value
+
+	if idx < 0 || idx > int64(len(array.Elements)-1) {
+		return NULL
+	}
+
+	return array.Elements[idx]
+}
+
+// character of string by index
+func evalStringIndexExp(left, index Object) Object {
+	str := left.(*String).Value
+	idx := index.(*Integer).Value
+
+	if idx < 0 {
+		return NULL
+	}
+
+	for _, r := range str {
+		if idx == 0 {
+			return &String{Value: string(r)}
+		}
+		idx--
+	}
+
+	return NULL
+}
+
+func evalHashMapIndexExp(hashMap, index Object) Object {
+	hashObject := hashMap.(*HashMap)
+
+	key, ok := index.(Hashable)
+	if !ok {
+		return NewError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
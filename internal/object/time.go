@@ -0,0 +1,148 @@
+package object
+
+import "time"
+
+func isTime(obj Object) bool {
+	return obj.Type() == DATETIME_OBJ || obj.Type() == DURATION_OBJ
+}
+
+// arithmetic of DATETIME and DURATION:
+// DATETIME - DATETIME = DURATION, DATETIME +- DURATION = DATETIME,
+// DURATION +- DURATION, DURATION * number, DURATION / number = DURATION, DURATION / DURATION = FLOAT.
+// Values of the same type can be compared
+func evalTimeInfixExpr(op string, left Object, right Object) Object {
+	switch l := left.(type) {
+	case *DateTime:
+		switch r := right.(type) {
+		case *DateTime:
+			if op == "-" {
+				return &Duration{Value: l.Value.Sub(r.Value)}
+			}
+
+			return compareTime(op, l.Value.Compare(r.Value), left, right)
+		case *Duration:
+			switch op {
+			case "+":
+				return &DateTime{Value: l.Value.Add(r.Value)}
+			case "-":
+				return &DateTime{Value: l.Value.Add(-r.Value)}
+			}
+		}
+	case *Duration:
+		switch r := right.(type) {
+		case *Duration:
+			switch op {
+			case "+":
+				return &Duration{Value: l.Value + r.Value}
+			case "-":
+				return &Duration{Value: l.Value - r.Value}
+			case "/":
+				if r.Value == 0 {
+					return NewError("division by zero")
+				}
+				return &Float{Value: float64(l.Value) / float64(r.Value)}
+			}
+
+			return compareTime(op, compareInt(int64(l.Value), int64(r.Value)), left, right)
+		case *DateTime:
+			if op == "+" {
+				return &DateTime{Value: r.Value.Add(l.Value)}
+			}
+		case *Integer, *Float:
+			switch op {
+			case "*":
+				return &Duration{Value: time.Duration(float64(l.Value) * toFloat(r))}
+			case "/":
+				if toFloat(r) == 0 {
+					return NewError("division by zero")
+				}
+				return &Duration{Value: time.Duration(float64(l.Value) / toFloat(r))}
+			}
+		}
+	case *Integer, *Float:
+		if r, ok := right.(*Duration); ok && op == "*" {
+			return &Duration{Value: time.Duration(toFloat(l) * float64(r.Value))}
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return NewError("type mismatch: %s %s %s", left.Type(), op, right.Type())
+	}
+
+	return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+}
+
+// cmp - result of comparison of left and right: -1, 0 or 1
+func compareTime(op string, cmp int, left, right Object) Object {
+	switch op {
+	case "==":
+		return boolToBooleanObj(cmp == 0)
+	case "!=":
+		return boolToBooleanObj(cmp != 0)
+	case "<":
+		return boolToBooleanObj(cmp < 0)
+	case ">":
+		return boolToBooleanObj(cmp > 0)
+	case "<=":
+		return boolToBooleanObj(cmp <= 0)
+	case ">=":
+		return boolToBooleanObj(cmp >= 0)
+	default:
+		return NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parts of date and time in its timezone, weekday: 1 - Monday ... 7 - Sunday
+func dateTimeMember(t time.Time, name string) Object {
+	switch name {
+	case "year":
+		return &Integer{Value: int64(t.Year())}
+	case "month":
+		return &Integer{Value: int64(t.Month())}
+	case "day":
+		return &Integer{Value: int64(t.Day())}
+	case "hour":
+		return &Integer{Value: int64(t.Hour())}
+	case "minute":
+		return &Integer{Value: int64(t.Minute())}
+	case "second":
+		return &Integer{Value: int64(t.Second())}
+	case "weekday":
+		return &Integer{Value: int64((t.Weekday()+6)%7 + 1)}
+	case "yearDay":
+		return &Integer{Value: int64(t.YearDay())}
+	case "unix":
+		return &Integer{Value: t.Unix()}
+	case "timezone":
+		return &String{Value: t.Location().String()}
+	default:
+		return NewError("unknown member of %s: %s", DATETIME_OBJ, name)
+	}
+}
+
+// total length of duration in units
+func durationMember(d time.Duration, name string) Object {
+	switch name {
+	case "hours":
+		return &Float{Value: d.Hours()}
+	case "minutes":
+		return &Float{Value: d.Minutes()}
+	case "seconds":
+		return &Float{Value: d.Seconds()}
+	case "milliseconds":
+		return &Integer{Value: d.Milliseconds()}
+	default:
+		return NewError("unknown member of %s: %s", DURATION_OBJ, name)
+	}
+}
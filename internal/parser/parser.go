@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/botscubes/bql/internal/ast"
 
@@ -13,6 +14,7 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	NULLISH     // ??
 	LOR         // ||
 	LAND        // &&
 	EQUALS      // ==
@@ -26,6 +28,7 @@ const (
 
 // TODO: create switch and move to token.go
 var precedences = map[token.TokenType]int{
+	token.NULLISH:  NULLISH,
 	token.LOR:      LOR,
 	token.LAND:     LAND,
 	token.EQ:       EQUALS,
@@ -41,6 +44,19 @@ var precedences = map[token.TokenType]int{
 	token.PERCENT:  PRODUCT,
 	token.LPAR:     CALL,
 	token.LBRACKET: INDEX,
+
+	token.DOT:               INDEX,
+	token.QUESTION_DOT:      INDEX,
+	token.QUESTION_LBRACKET: INDEX,
+}
+
+var assignOperators = map[token.TokenType]bool{
+	token.ASSIGN:         true,
+	token.PLUS_ASSIGN:    true,
+	token.MINUS_ASSIGN:   true,
+	token.STAR_ASSIGN:    true,
+	token.SLASH_ASSIGN:   true,
+	token.PERCENT_ASSIGN: true,
 }
 
 type (
@@ -52,12 +68,23 @@ type Parser struct {
 	l         *lexer.Lexer
 	curToken  token.Token
 	peekToken token.Token
-	peekPos   token.Pos
+	peekPos   token.Pos // position of first char of peekToken
+	peekEnd   token.Pos // position of char after peekToken
 	curPos    token.Pos
+	curEnd    token.Pos
 
 	prefixParsers map[token.TokenType]prefixParseFn
 	infixParsers  map[token.TokenType]infixParseFn
-	errors        []string
+	errors        []Error
+
+	// number of loops around current statement (in current function)
+	loopDepth int
+	// statement is inside if expression whose value is used, break and continue can't jump out of it
+	inValue bool
+	// next if expression is statement, its value is not used
+	stmtIf bool
+	// number of parsed break and continue
+	jumps int
 }
 
 func New(l *lexer.Lexer) *Parser {
@@ -69,13 +96,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParsers = make(map[token.TokenType]prefixParseFn)
 	p.prefixParsers[token.IDENT] = p.parseIdent
 	p.prefixParsers[token.INT] = p.parseInteger
+	p.prefixParsers[token.FLOAT] = p.parseFloat
+	p.prefixParsers[token.DECIMAL] = p.parseDecimal
 	p.prefixParsers[token.MINUS] = p.parsePrefixExpression
 	p.prefixParsers[token.EXCLAMINATION] = p.parsePrefixExpression
 	p.prefixParsers[token.TRUE] = p.parseBoolean
 	p.prefixParsers[token.FALSE] = p.parseBoolean
+	p.prefixParsers[token.NULL] = p.parseNull
 	p.prefixParsers[token.LPAR] = p.parseGroupedExpression
 	p.prefixParsers[token.IF] = p.parseIfExpression
 	p.prefixParsers[token.STRING] = p.parseString
+	p.prefixParsers[token.TEMPLATE] = p.parseTemplate
+	p.prefixParsers[token.TEMPLATE_HEAD] = p.parseTemplate
 	p.prefixParsers[token.LBRACKET] = p.parseArray
 	p.prefixParsers[token.FUNC] = p.parseFunction
 	p.prefixParsers[token.LBRACE] = p.parseHashMapLiteral
@@ -95,8 +127,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.infixParsers[token.GT] = p.parseInfixExpression
 	p.infixParsers[token.LOR] = p.parseInfixExpression
 	p.infixParsers[token.LAND] = p.parseInfixExpression
+	p.infixParsers[token.NULLISH] = p.parseInfixExpression
 	p.infixParsers[token.LPAR] = p.parseCallExpression
 	p.infixParsers[token.LBRACKET] = p.parseIndexExpression
+	p.infixParsers[token.QUESTION_LBRACKET] = p.parseIndexExpression
+	p.infixParsers[token.DOT] = p.parseMemberExpression
+	p.infixParsers[token.QUESTION_DOT] = p.parseMemberExpression
 
 	// read curToken and peekToken
 	p.nextToken()
@@ -105,19 +141,48 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-func (p *Parser) Errors() []string {
+type Error struct {
+	Pos     token.Pos
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("pos: %d:%d: %s", e.Pos.Line, e.Pos.Offset, e.Message)
+}
+
+// returns syntax errors. Errors of lexer (illegal characters) are not included, see lexer.Errors()
+func (p *Parser) Errors() []Error {
 	return p.errors
 }
 
+// error at current token
 func (p *Parser) newError(e string) {
-	mes := fmt.Sprintf("pos: %d:%d: %s", p.curPos.Line, p.curPos.Offset, e)
-	p.errors = append(p.errors, mes)
+	p.newErrorAt(p.curPos, e)
+}
+
+func (p *Parser) newErrorAt(pos token.Pos, e string) {
+	p.errors = append(p.errors, Error{Pos: pos, Message: e})
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.curPos = p.peekPos
+	p.curEnd = p.peekEnd
 	p.peekToken, p.peekPos = p.l.NextToken()
+	p.peekEnd = p.l.Pos()
+}
+
+// span of current token
+func (p *Parser) curSpan() ast.Span {
+	return ast.Span{StartPos: p.curPos, EndPos: p.curEnd}
+}
+
+// span from start of node to end of current token
+func (p *Parser) spanFrom(node ast.Node) ast.Span {
+	if node == nil {
+		return p.curSpan()
+	}
+	return ast.Span{StartPos: node.Pos(), EndPos: p.curEnd}
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -141,7 +206,10 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 		p.nextToken()
 		return true
 	} else {
-		p.newError(fmt.Sprintf("expected next token: %s, got %s", t, p.peekToken.Type))
+		// illegal token is already reported by lexer
+		if !p.peekTokenIs(token.ILLEGAL) {
+			p.newErrorAt(p.peekPos, fmt.Sprintf("expected next token: %s, got %s", t, p.peekToken.Type))
+		}
 		return false
 	}
 }
@@ -166,7 +234,9 @@ func (p *Parser) curPrecedence() int {
 func (p *Parser) expectSemi() bool {
 	if !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		if !p.curTokenIs(token.SEMICOLON) {
-			p.newError(fmt.Sprintf("expected ; at end of statement, got %s", p.curToken.Literal))
+			if !p.curTokenIs(token.ILLEGAL) {
+				p.newError(fmt.Sprintf("expected ; at end of statement, got %s", p.curToken.Literal))
+			}
 			return false
 		}
 		p.nextToken()
@@ -197,42 +267,139 @@ func (p *Parser) ParseProgram() *ast.Program {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.IDENT:
-		if p.peekTokenIs(token.ASSIGN) {
+		if assignOperators[p.peekToken.Type] {
 			return p.parseAssignStatement()
 		}
 
-		return p.parseExpressionStatement()
+		return p.parseExpressionOrIndexAssign()
+	case token.LET, token.CONST:
+		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		if !p.checkJump() {
+			return nil
+		}
+		return &ast.BreakStatement{Span: p.curSpan(), Token: p.curToken}
+	case token.CONTINUE:
+		if !p.checkJump() {
+			return nil
+		}
+		return &ast.ContinueStatement{Span: p.curSpan(), Token: p.curToken}
 	default:
-		return p.parseExpressionStatement()
+		return p.parseExpressionOrIndexAssign()
 	}
 }
 
+// break and continue must be inside loop and can't be used as value: x = [1, if (c) { break }]
+func (p *Parser) checkJump() bool {
+	switch {
+	case p.loopDepth == 0:
+		p.newError(p.curToken.Literal + " outside loop")
+	case p.inValue:
+		p.newError(p.curToken.Literal + " in expression")
+	default:
+		p.jumps++
+		return true
+	}
+
+	return false
+}
+
 func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 	stmt := &ast.AssignStatement{
-		Name: &ast.Ident{Token: p.curToken, Value: p.curToken.Literal},
+		Span:     p.curSpan(),
+		Name:     &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal},
+		Operator: p.peekToken.Literal,
 	}
 
-	// skip ident and =
+	// skip ident and assignment operator
 	p.nextToken()
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	stmt.EndPos = p.curEnd
 
 	return stmt
 }
 
+func (p *Parser) parseLetStatement() ast.Statement {
+	stmt := &ast.LetStatement{Span: p.curSpan(), Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+	stmt.EndPos = p.curEnd
+
+	return stmt
+}
+
+// expression statement or assignment to element if expression is followed by assignment operator
+func (p *Parser) parseExpressionOrIndexAssign() ast.Statement {
+	stmt := p.parseExpressionStatement()
+	if !assignOperators[p.peekToken.Type] {
+		return stmt
+	}
+
+	var valid bool
+	switch target := stmt.Expression.(type) {
+	case *ast.IndexExpression:
+		valid = !target.Optional
+	case *ast.MemberExpression:
+		valid = !target.Optional
+	}
+
+	if !valid {
+		p.newErrorAt(p.peekPos, "invalid assignment target")
+	}
+
+	assign := &ast.IndexAssignStatement{Span: stmt.Span, Target: stmt.Expression, Operator: p.peekToken.Literal}
+
+	// skip assignment operator
+	p.nextToken()
+	p.nextToken()
+
+	assign.Value = p.parseExpression(LOWEST)
+	assign.EndPos = p.curEnd
+
+	if !valid {
+		return nil
+	}
+
+	return assign
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt := &ast.ExpressionStatement{Span: p.curSpan(), Token: p.curToken}
+
+	// if statement can contain break and continue, unless it is operand: if (c) { break } + 1
+	p.stmtIf = p.curTokenIs(token.IF)
+	jumps := p.jumps
 
 	stmt.Expression = p.parseExpression(LOWEST)
+	stmt.EndPos = p.curEnd
+
+	if _, ok := stmt.Expression.(*ast.IfExpression); p.jumps != jumps && !ok {
+		p.newErrorAt(stmt.StartPos, "break or continue in expression")
+	}
 
 	return stmt
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{Token: p.curToken}
+	block := &ast.BlockStatement{Span: p.curSpan(), Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
 	p.nextToken()
@@ -249,15 +416,18 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 	}
 
+	block.EndPos = p.curEnd
+
 	return block
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
-	stmt := &ast.ReturnStatement{Token: p.curToken}
+	stmt := &ast.ReturnStatement{Span: p.curSpan(), Token: p.curToken}
 
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	stmt.EndPos = p.curEnd
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -266,10 +436,92 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Span: p.curSpan(), Token: p.curToken}
+
+	if !p.expectPeek(token.LPAR) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAR) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	stmt.EndPos = p.curEnd
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Span: p.curSpan(), Token: p.curToken}
+
+	if !p.expectPeek(token.LPAR) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Value = &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.skipPeek(token.COMMA) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAR) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	stmt.EndPos = p.curEnd
+
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	// break and continue of nested loop jump out of it only
+	p.loopDepth++
+	inValue, jumps := p.inValue, p.jumps
+	p.inValue = false
+	defer func() {
+		p.loopDepth--
+		p.inValue, p.jumps = inValue, jumps
+	}()
+
+	return p.parseBlockStatement()
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParsers[p.curToken.Type]
 	if prefix == nil {
-		p.newError(fmt.Sprintf("prefix parse function for %s not found", p.curToken.Type))
+		if !p.curTokenIs(token.ILLEGAL) {
+			p.newError(fmt.Sprintf("prefix parse function for %s not found", p.curToken.Type))
+		}
 		return nil
 	}
 	leftExp := prefix()
@@ -289,7 +541,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) parseFunction() ast.Expression {
-	node := &ast.FunctionLiteral{Token: p.curToken}
+	node := &ast.FunctionLiteral{Span: p.curSpan(), Token: p.curToken}
 
 	if !p.expectPeek(token.LPAR) {
 		return nil
@@ -301,7 +553,13 @@ func (p *Parser) parseFunction() ast.Expression {
 		return nil
 	}
 
+	// break and continue can't jump out of function
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	node.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+
+	node.EndPos = p.curEnd
 
 	return node
 }
@@ -321,7 +579,7 @@ func (p *Parser) parseFunctionParameters() []*ast.Ident {
 			return nil
 		}
 
-		ident := &ast.Ident{Token: p.curToken, Value: p.curToken.Literal}
+		ident := &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
 		if !p.peekTokenIs(token.COMMA) {
 			break
@@ -338,7 +596,7 @@ func (p *Parser) parseFunctionParameters() []*ast.Ident {
 }
 
 func (p *Parser) parseInteger() ast.Expression {
-	node := &ast.IntegerLiteral{Token: p.curToken}
+	node := &ast.IntegerLiteral{Span: p.curSpan(), Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
@@ -351,12 +609,35 @@ func (p *Parser) parseInteger() ast.Expression {
 	return node
 }
 
+func (p *Parser) parseFloat() ast.Expression {
+	node := &ast.FloatLiteral{Span: p.curSpan(), Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.newError(fmt.Sprintf("failed parse %q as float", p.curToken.Literal))
+		return nil
+	}
+
+	node.Value = value
+
+	return node
+}
+
+func (p *Parser) parseDecimal() ast.Expression {
+	value := strings.TrimSuffix(p.curToken.Literal, "d")
+	return &ast.DecimalLiteral{Span: p.curSpan(), Token: p.curToken, Value: value}
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
-	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+	return &ast.Boolean{Span: p.curSpan(), Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.Null{Span: p.curSpan(), Token: p.curToken}
 }
 
 func (p *Parser) parseIdent() ast.Expression {
-	return &ast.Ident{Token: p.curToken, Value: p.curToken.Literal}
+	return &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
@@ -373,6 +654,7 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
+		Span:     p.curSpan(),
 		Token:    p.curToken,
 		Operator: p.curToken.Literal,
 	}
@@ -380,6 +662,7 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	p.nextToken()
 
 	expression.Right = p.parseExpression(PREFIX)
+	expression.EndPos = p.curEnd
 
 	return expression
 }
@@ -394,12 +677,18 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	prec := p.curPrecedence()
 	p.nextToken()
 	expression.Right = p.parseExpression(prec)
+	expression.Span = p.spanFrom(left)
 
 	return expression
 }
 
 func (p *Parser) parseIfExpression() ast.Expression {
-	expression := &ast.IfExpression{Token: p.curToken}
+	expression := &ast.IfExpression{Span: p.curSpan(), Token: p.curToken}
+
+	inValue := p.inValue
+	p.inValue = inValue || !p.stmtIf
+	p.stmtIf = false
+	defer func() { p.inValue = inValue }()
 
 	if !p.expectPeek(token.LPAR) {
 		return nil
@@ -428,12 +717,15 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		expression.Alternative = p.parseBlockStatement()
 	}
 
+	expression.EndPos = p.curEnd
+
 	return expression
 }
 
 func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: fn}
 	exp.Arguments = p.parseExpressionList(token.RPAR)
+	exp.Span = p.spanFrom(fn)
 	return exp
 }
 
@@ -462,30 +754,111 @@ func (p *Parser) parseExpressionList(endToken token.TokenType) []ast.Expression
 }
 
 func (p *Parser) parseString() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	return &ast.StringLiteral{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// TEMPLATE or TEMPLATE_HEAD followed by expressions separated by TEMPLATE_MIDDLE and ended by TEMPLATE_TAIL
+func (p *Parser) parseTemplate() ast.Expression {
+	tmpl := &ast.TemplateLiteral{Token: p.curToken}
+	start := p.curPos
+
+	for {
+		if p.curToken.Literal != "" {
+			tmpl.Parts = append(tmpl.Parts, &ast.StringLiteral{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal})
+		}
+
+		if p.curTokenIs(token.TEMPLATE) || p.curTokenIs(token.TEMPLATE_TAIL) {
+			break
+		}
+
+		if p.peekTokenIs(token.TEMPLATE_MIDDLE) || p.peekTokenIs(token.TEMPLATE_TAIL) {
+			p.newErrorAt(p.peekPos, "empty expression in template")
+			return nil
+		}
+		p.nextToken()
+
+		exp := p.parseExpression(LOWEST)
+		if exp == nil {
+			return nil
+		}
+		tmpl.Parts = append(tmpl.Parts, exp)
+
+		if !p.peekTokenIs(token.TEMPLATE_MIDDLE) && !p.peekTokenIs(token.TEMPLATE_TAIL) {
+			// illegal token is already reported by lexer
+			if !p.peekTokenIs(token.ILLEGAL) {
+				p.newErrorAt(p.peekPos, fmt.Sprintf("expected } in template, got %s", p.peekToken.Type))
+			}
+			return nil
+		}
+		p.nextToken()
+	}
+
+	tmpl.Span = ast.Span{StartPos: start, EndPos: p.curEnd}
+
+	return tmpl
 }
 
 func (p *Parser) parseArray() ast.Expression {
-	array := &ast.ArrayLiteral{Token: p.curToken}
+	array := &ast.ArrayLiteral{Span: p.curSpan(), Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.EndPos = p.curEnd
 	return array
 }
 
+// left[index] or slice left[low:high]
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
+	optional := p.curTokenIs(token.QUESTION_LBRACKET)
 
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+
+	var index ast.Expression
+	if !p.curTokenIs(token.COLON) {
+		index = p.parseExpression(LOWEST)
+
+		if !p.peekTokenIs(token.COLON) {
+			if !p.expectPeek(token.RBRACKET) {
+				return nil
+			}
+
+			return &ast.IndexExpression{Span: p.spanFrom(left), Token: tok, Left: left, Index: index, Optional: optional}
+		}
+		p.nextToken()
+	}
+
+	exp := &ast.SliceExpression{Token: tok, Left: left, Low: index, Optional: optional}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.High = p.parseExpression(LOWEST)
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 
+	exp.Span = p.spanFrom(left)
+
+	return exp
+}
+
+// left.name is the same as left["name"], name can be keyword
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Left: left, Optional: p.curTokenIs(token.QUESTION_DOT)}
+
+	if p.peekToken.Literal == "" || token.LookupIdent(p.peekToken.Literal) != p.peekToken.Type {
+		p.newErrorAt(p.peekPos, fmt.Sprintf("expected name of member, got %s", p.peekToken.Type))
+		return nil
+	}
+	p.nextToken()
+
+	exp.Property = &ast.Ident{Span: p.curSpan(), Token: p.curToken, Value: p.curToken.Literal}
+	exp.Span = p.spanFrom(left)
+
 	return exp
 }
 
 func (p *Parser) parseHashMapLiteral() ast.Expression {
-	hash := &ast.HashMapLiteral{Token: p.curToken}
+	hash := &ast.HashMapLiteral{Span: p.curSpan(), Token: p.curToken}
 	hash.Pairs = make(map[ast.Expression]ast.Expression)
 
 	for !p.peekTokenIs(token.RBRACE) {
@@ -499,6 +872,7 @@ func (p *Parser) parseHashMapLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.skipPeek(token.SEMICOLON) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -509,5 +883,7 @@ func (p *Parser) parseHashMapLiteral() ast.Expression {
 		return nil
 	}
 
+	hash.EndPos = p.curEnd
+
 	return hash
 }
@@ -6,12 +6,17 @@ import (
 
 	"github.com/botscubes/bql/internal/ast"
 	"github.com/botscubes/bql/internal/lexer"
+	"github.com/botscubes/bql/internal/token"
 )
 
 func checkParserErrors(t *testing.T, p *Parser) {
+	lexerErrors := p.l.Errors()
 	errors := p.Errors()
-	if len(errors) != 0 {
-		t.Errorf("parser has %d errors:", len(errors))
+	if len(errors) != 0 || len(lexerErrors) != 0 {
+		t.Errorf("parser has %d errors:", len(errors)+len(lexerErrors))
+		for _, e := range lexerErrors {
+			t.Errorf("lexer error: %q", e)
+		}
 		for _, e := range errors {
 			t.Errorf("parser error: %q", e)
 		}
@@ -58,6 +63,49 @@ func TestParseAssignStatement(t *testing.T) {
 	}
 }
 
+func TestParseLetStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		ident    string
+		value    any
+		constant bool
+	}{
+		{"let x = 5", "x", 5, false},
+		{"const y = true", "y", true, true},
+		{"let z = y", "z", "y", false},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(result.Statements) != 1 {
+			t.Fatalf("program has incorrect number of statements. got:%d",
+				len(result.Statements))
+		}
+
+		stmt, ok := result.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("result.Statements[0] is not ast.LetStatement. got:%T",
+				result.Statements[0])
+		}
+
+		if stmt.IsConst() != test.constant {
+			t.Errorf("wrong kind of declaration. got: %s", stmt.TokenLiteral())
+		}
+
+		if !testIdent(t, stmt.Name, test.ident) {
+			return
+		}
+
+		if !testLiteralExpression(t, stmt.Value, test.value) {
+			return
+		}
+	}
+}
+
 func TestParseReturnStatement(t *testing.T) {
 	tests := []struct {
 		input string
@@ -286,6 +334,30 @@ func TestOperatorPrecedence(t *testing.T) {
 			"a > b && c",
 			"((a > b) && c)",
 		},
+		{
+			"a || b ?? c == null",
+			"((a || b) ?? (c == null))",
+		},
+		{
+			`user?.profile?["phone"] ?? "unknown"`,
+			"(((user?.profile)?[phone]) ?? unknown)",
+		},
+		{
+			"a?.b + c[1]",
+			"((a?.b) + (c[1]))",
+		},
+		{
+			"m.e.l2a * 2",
+			"(((m.e).l2a) * 2)",
+		},
+		{
+			"-m.a[0]",
+			"(-((m.a)[0]))",
+		},
+		{
+			"m.if.null",
+			"((m.if).null)",
+		},
 	}
 
 	for _, test := range tests {
@@ -560,6 +632,46 @@ func TestParseFunction(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "*", "y")
 }
 
+func TestParseFloat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"0.5", 0.5},
+		{"1e3", 1000},
+		{"2.5E-2", 0.025},
+		{"7e+1", 70},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := result.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("result.Statements[0] is not ast.ExpressionStatement. got:%T",
+				result.Statements[0])
+		}
+
+		fl, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FloatLiteral. got:%T",
+				stmt.Expression)
+		}
+
+		if fl.Value != test.expected {
+			t.Errorf("fl.Value not %g got:%g", test.expected, fl.Value)
+		}
+
+		if fl.TokenLiteral() != test.input {
+			t.Errorf("fl.TokenLiteral not %q got:%q", test.input, fl.TokenLiteral())
+		}
+	}
+}
+
 func TestParseString(t *testing.T) {
 	input := `"abc qqqr"`
 
@@ -585,6 +697,43 @@ func TestParseString(t *testing.T) {
 	}
 }
 
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		parts    int
+	}{
+		{"`plain`", "`plain`", 1},
+		{"``", "``", 0},
+		{"`Hi, ${name}!`", "`Hi, ${name}!`", 3},
+		{"`${a + b * 2}`", "`${(a + (b * 2))}`", 1},
+		{"`${a}${b}`", "`${a}${b}`", 2},
+		{"`x${`y${z}`}`", "`x${`y${z}`}`", 2},
+		{"`${ {\"a\": 1}[\"a\"] }`", "`${({a:1}[a])}`", 1},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := result.Statements[0].(*ast.ExpressionStatement)
+		tmpl, ok := stmt.Expression.(*ast.TemplateLiteral)
+		if !ok {
+			t.Fatalf("expression is not ast.TemplateLiteral. got: %T", stmt.Expression)
+		}
+
+		if len(tmpl.Parts) != test.parts {
+			t.Errorf("wrong number of parts in %s. expected: %d got: %d", test.input, test.parts, len(tmpl.Parts))
+		}
+
+		if tmpl.ToString() != test.expected {
+			t.Errorf("wrong template. expected: %q got: %q", test.expected, tmpl.ToString())
+		}
+	}
+}
+
 func TestParseArray(t *testing.T) {
 	input := "[5, 25 + 1, a, 5 * 3]"
 
@@ -642,6 +791,94 @@ func TestParseIndexExpression(t *testing.T) {
 	testInfixExpression(t, exp.Index, 5, "+", 1)
 }
 
+func TestParseSliceExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1:3]", "(s[1:3])"},
+		{"s[:n - 1]", "(s[:(n - 1)])"},
+		{"s[2:]", "(s[2:])"},
+		{"s[:]", "(s[:])"},
+		{"m?[1:2]", "(m?[1:2])"},
+		{"a[1:][0]", "((a[1:])[0])"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := result.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.SliceExpression); !ok && test.input != "a[1:][0]" {
+			t.Errorf("expression is not ast.SliceExpression. got: %T", stmt.Expression)
+		}
+
+		if stmt.Expression.ToString() != test.expected {
+			t.Errorf("wrong expression. expected: %q got: %q", test.expected, stmt.Expression.ToString())
+		}
+	}
+}
+
+func TestParseIndexAssignStatement(t *testing.T) {
+	input := "m.e.l2a = 5 + 1"
+
+	l := lexer.New(input)
+	p := New(l)
+	result := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := result.Statements[0].(*ast.IndexAssignStatement)
+	if !ok {
+		t.Fatalf("result.Statements[0] is not ast.IndexAssignStatement. got:%T",
+			result.Statements[0])
+	}
+
+	target, ok := stmt.Target.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("stmt.Target is not ast.MemberExpression. got:%T", stmt.Target)
+	}
+
+	if target.Property.Value != "l2a" || target.Left.ToString() != "(m.e)" {
+		t.Errorf("wrong target. got: %s", target.ToString())
+	}
+
+	testInfixExpression(t, stmt.Value, 5, "+", 1)
+}
+
+func TestParseCompoundAssign(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x += 1", "x += 1;"},
+		{"x -= y * 2", "x -= (y * 2);"},
+		{"x *= 2", "x *= 2;"},
+		{"x /= 2", "x /= 2;"},
+		{"x %= 2", "x %= 2;"},
+		{"a[0] = 1", "(a[0]) = 1;"},
+		{"m[\"k\"][1] += 2", "((m[k])[1]) += 2;"},
+		{"m.a[i + 1] -= 3", "((m.a)[(i + 1)]) -= 3;"},
+		{"m.n %= 4", "(m.n) %= 4;"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(result.Statements) != 1 {
+			t.Fatalf("program has incorrect number of statements. got:%d", len(result.Statements))
+		}
+
+		if result.Statements[0].ToString() != test.expected {
+			t.Errorf("wrong statement. expected: %q got: %q", test.expected, result.Statements[0].ToString())
+		}
+	}
+}
+
 func TestParseEmptyHashMap(t *testing.T) {
 	input := "{}"
 
@@ -787,6 +1024,241 @@ func TestParseHashMapIntegerKeys(t *testing.T) {
 	}
 }
 
+func TestParseWhileStatement(t *testing.T) {
+	input := `while (x < y) { x = x + 1 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	result := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(result.Statements) != 1 {
+		t.Fatalf("program has incorrect number of statements. got:%d",
+			len(result.Statements))
+	}
+
+	stmt, ok := result.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("result.Statements[0] is not ast.WhileStatement. got:%T",
+			result.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("stmt.Body.Statements has incorrect number of statements. got:%d",
+			len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[0].(*ast.AssignStatement); !ok {
+		t.Fatalf("stmt.Body.Statements[0] is not ast.AssignStatement. got:%T",
+			stmt.Body.Statements[0])
+	}
+}
+
+func TestParseForStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		key   string
+		value string
+	}{
+		{"for (x in arr) { x }", "", "x"},
+		{"for (k, v in arr) { v }", "k", "v"},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		result := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(result.Statements) != 1 {
+			t.Fatalf("program has incorrect number of statements. got:%d",
+				len(result.Statements))
+		}
+
+		stmt, ok := result.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("result.Statements[0] is not ast.ForStatement. got:%T",
+				result.Statements[0])
+		}
+
+		if test.key == "" {
+			if stmt.Key != nil {
+				t.Fatalf("stmt.Key is not nil. got:%+v", stmt.Key)
+			}
+		} else if !testIdent(t, stmt.Key, test.key) {
+			return
+		}
+
+		if !testIdent(t, stmt.Value, test.value) {
+			return
+		}
+
+		if !testIdent(t, stmt.Iterable, "arr") {
+			return
+		}
+
+		if len(stmt.Body.Statements) != 1 {
+			t.Fatalf("stmt.Body.Statements has incorrect number of statements. got:%d",
+				len(stmt.Body.Statements))
+		}
+	}
+}
+
+func TestParseBreakContinue(t *testing.T) {
+	tests := []struct {
+		input       string
+		errorsCount int
+	}{
+		{"while (true) { break }", 0},
+		{"while (true) { if (x) { continue } }", 0},
+		{"for (x in a) { while (true) { break }; continue }", 0},
+		{"break", 1},
+		{"continue", 1},
+		{"if (true) { break }", 1},
+		{"while (true) { f = fn() { break } }", 1},
+		{"while (true) { if (a) { if (b) { break } } else { continue } }", 0},
+		{"while (true) { x = if (c) { 1 } else { while (true) { break } } }", 0},
+		{"while (true) { if (c) { while (true) { break } } + 1 }", 0},
+		{"while (true) { x = [1, if (c) { break } else { 0 }] }", 1},
+		{"while (true) { x = if (c) { if (d) { continue } } }", 1},
+		{"while (true) { f(if (c) { break }) }", 1},
+		{"while (true) { return if (c) { break } }", 1},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != test.errorsCount {
+			t.Errorf("wrong number of errors for %q. expected: %d got:%d (%v)",
+				test.input, test.errorsCount, len(p.Errors()), p.Errors())
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"x = ", []string{"pos: 1:4: prefix parse function for EOF not found"}},
+		{"x = 1\ny = (2 + 3", []string{"pos: 2:10: expected next token: ), got EOF"}},
+		{"x = 1 2", []string{"pos: 1:6: expected ; at end of statement, got 2"}},
+		{"if (x) { 1 } else 2", []string{
+			"pos: 1:18: expected next token: {, got INT",
+			"pos: 1:18: expected ; at end of statement, got 2",
+		}},
+		// illegal characters are reported by lexer
+		{"x = 1 $ 2", []string{}},
+		{"x = $", []string{}},
+		{"m.1", []string{
+			"pos: 1:2: expected name of member, got INT",
+			"pos: 1:2: expected ; at end of statement, got 1",
+		}},
+		{"f() = 1", []string{"pos: 1:4: invalid assignment target"}},
+		{"m?.a = 1", []string{"pos: 1:5: invalid assignment target"}},
+		{"a?[0] += 1", []string{"pos: 1:6: invalid assignment target"}},
+		{"1 -= 1", []string{"pos: 1:2: invalid assignment target"}},
+		{"s[1:2] = 1", []string{"pos: 1:7: invalid assignment target"}},
+		{"`a ${} b`", []string{
+			"pos: 1:5: empty expression in template",
+			"pos: 1:5: expected ; at end of statement, got  b",
+		}},
+		{"`a ${x y} b`", []string{
+			"pos: 1:7: expected } in template, got IDENT",
+			"pos: 1:7: expected ; at end of statement, got y",
+		}},
+		{"let = 1", []string{
+			"pos: 1:4: expected next token: IDENT, got =",
+			"pos: 1:4: expected ; at end of statement, got =",
+		}},
+		{"const x += 1", []string{
+			"pos: 1:8: expected next token: =, got +=",
+			"pos: 1:8: expected ; at end of statement, got +=",
+		}},
+		{"i = 0; while (true) { i += 1; x = [1, if (i > 3) { break } else { 0 }] }; i", []string{
+			"pos: 1:51: break in expression",
+		}},
+		{"while (true) { x = 1 + if (true) { continue } else { 0 } }", []string{
+			"pos: 1:35: continue in expression",
+		}},
+		{"while (true) { if (true) { continue } + 1 }", []string{
+			"pos: 1:15: break or continue in expression",
+		}},
+	}
+
+	for _, test := range tests {
+		l := lexer.New(test.input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != len(test.expected) {
+			t.Errorf("wrong number of errors for %q. expected: %d got:%d (%v)",
+				test.input, len(test.expected), len(p.Errors()), p.Errors())
+			continue
+		}
+
+		for i, e := range p.Errors() {
+			if e.Error() != test.expected[i] {
+				t.Errorf("wrong error. expected: %q got:%q", test.expected[i], e.Error())
+			}
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `x = 1 + foo(2)
+if (x > 1) {
+	a[0]
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	result := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	assign := result.Statements[0].(*ast.AssignStatement)
+	infix := assign.Value.(*ast.InfixExpression)
+	call := infix.Right.(*ast.CallExpression)
+	ifExp := result.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	index := ifExp.Consequence.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IndexExpression)
+
+	tests := []struct {
+		node  ast.Node
+		start token.Pos
+		end   token.Pos
+	}{
+		{assign, token.Pos{Line: 1, Offset: 0}, token.Pos{Line: 1, Offset: 14}},
+		{assign.Name, token.Pos{Line: 1, Offset: 0}, token.Pos{Line: 1, Offset: 1}},
+		{infix, token.Pos{Line: 1, Offset: 4}, token.Pos{Line: 1, Offset: 14}},
+		{infix.Left, token.Pos{Line: 1, Offset: 4}, token.Pos{Line: 1, Offset: 5}},
+		{call, token.Pos{Line: 1, Offset: 8}, token.Pos{Line: 1, Offset: 14}},
+		{call.Arguments[0], token.Pos{Line: 1, Offset: 12}, token.Pos{Line: 1, Offset: 13}},
+		{ifExp, token.Pos{Line: 2, Offset: 0}, token.Pos{Line: 4, Offset: 1}},
+		{ifExp.Condition, token.Pos{Line: 2, Offset: 4}, token.Pos{Line: 2, Offset: 9}},
+		{ifExp.Consequence, token.Pos{Line: 2, Offset: 11}, token.Pos{Line: 4, Offset: 1}},
+		{index, token.Pos{Line: 3, Offset: 1}, token.Pos{Line: 3, Offset: 5}},
+		{result, token.Pos{Line: 1, Offset: 0}, token.Pos{Line: 4, Offset: 1}},
+	}
+
+	for i, test := range tests {
+		if test.node.Pos() != test.start {
+			t.Errorf("tests[%d] - wrong start pos of %q. expected: %+v got:%+v",
+				i, test.node.ToString(), test.start, test.node.Pos())
+		}
+
+		if test.node.End() != test.end {
+			t.Errorf("tests[%d] - wrong end pos of %q. expected: %+v got:%+v",
+				i, test.node.ToString(), test.end, test.node.End())
+		}
+	}
+}
+
 func testInfixExpression(
 	t *testing.T,
 	exp ast.Expression,
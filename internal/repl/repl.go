@@ -0,0 +1,237 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/botscubes/bql/internal/ast"
+	"github.com/botscubes/bql/internal/evaluator"
+	"github.com/botscubes/bql/internal/lexer"
+	"github.com/botscubes/bql/internal/object"
+	"github.com/botscubes/bql/internal/parser"
+	"github.com/botscubes/bql/internal/token"
+)
+
+const (
+	PROMPT      = ">> "
+	CONT_PROMPT = ".. " // prompt for next line of unfinished input
+
+	HELP = `commands:
+  :env           show variables
+  :ast [code]    show AST of code or of last input
+  :tokens [code] show tokens of code or of last input
+  :history [N]   show entered inputs or run input N again
+  :help          show this help
+  :quit          exit
+unfinished input (open brackets) continues on next line`
+)
+
+// default call depth protects REPL from infinite recursion
+var limits = object.Limits{}
+
+type Repl struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	env     *object.Env
+	history []string
+}
+
+func New(in io.Reader, out io.Writer) *Repl {
+	return &Repl{
+		in:  bufio.NewScanner(in),
+		out: out,
+		env: object.NewEnv(),
+	}
+}
+
+// reads inputs until EOF or :quit, variables are kept between inputs
+func (r *Repl) Start() {
+	for {
+		input, ok := r.read()
+		if !ok {
+			return
+		}
+
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(input, ":") {
+			if !r.command(input) {
+				return
+			}
+			continue
+		}
+
+		r.run(input)
+	}
+}
+
+// adds input to history and evaluates it
+func (r *Repl) run(input string) {
+	r.history = append(r.history, input)
+	r.eval(input)
+}
+
+// reads lines while input has unclosed brackets
+func (r *Repl) read() (string, bool) {
+	fmt.Fprint(r.out, PROMPT)
+
+	var lines []string
+	for r.in.Scan() {
+		lines = append(lines, r.in.Text())
+		input := strings.Join(lines, "\n")
+
+		if strings.HasPrefix(input, ":") || openBrackets(input) <= 0 {
+			return input, true
+		}
+
+		fmt.Fprint(r.out, CONT_PROMPT)
+	}
+
+	if len(lines) != 0 {
+		return strings.Join(lines, "\n"), true
+	}
+
+	fmt.Fprintln(r.out)
+	return "", false
+}
+
+// returns number of brackets which are opened but not closed
+func openBrackets(input string) int {
+	l := lexer.New(input)
+
+	depth := 0
+	for tok, _ := l.NextToken(); tok.Type != token.EOF; tok, _ = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LPAR, token.LBRACKET:
+			depth++
+		case token.RBRACE, token.RPAR, token.RBRACKET:
+			depth--
+		}
+	}
+
+	return depth
+}
+
+// returns false if REPL must be stopped
+func (r *Repl) command(input string) bool {
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":quit", ":exit", ":q":
+		return false
+	case ":help":
+		fmt.Fprintln(r.out, HELP)
+	case ":env":
+		r.printEnv()
+	case ":ast":
+		if program, ok := r.parse(r.codeOrLast(arg)); ok {
+			fmt.Fprint(r.out, program.Tree())
+		}
+	case ":tokens":
+		printTokens(r.out, r.codeOrLast(arg))
+	case ":history":
+		r.recall(arg)
+	default:
+		fmt.Fprintf(r.out, "unknown command: %s (see :help)\n", name)
+	}
+
+	return true
+}
+
+// prints history without argument, otherwise runs input with given number again
+func (r *Repl) recall(arg string) {
+	if arg == "" {
+		for id, h := range r.history {
+			fmt.Fprintf(r.out, "%d: %s\n", id+1, h)
+		}
+		return
+	}
+
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(r.history) {
+		fmt.Fprintf(r.out, "no input in history: %s\n", arg)
+		return
+	}
+
+	input := r.history[n-1]
+	fmt.Fprintln(r.out, input)
+	r.run(input)
+}
+
+func (r *Repl) codeOrLast(code string) string {
+	if code == "" && len(r.history) != 0 {
+		return r.history[len(r.history)-1]
+	}
+
+	return code
+}
+
+func (r *Repl) eval(input string) {
+	program, ok := r.parse(input)
+	if !ok {
+		return
+	}
+
+	e := evaluator.New(context.Background(), limits)
+	ev := e.Eval(program, r.env)
+	if ev == nil {
+		return
+	}
+
+	if err, ok := ev.(*object.Error); ok {
+		fmt.Fprintf(r.out, "runtime error: %d:%d: %s\n", err.Pos.Line, err.Pos.Offset+1, err.Message)
+		return
+	}
+
+	fmt.Fprintln(r.out, ev.ToString())
+}
+
+// prints errors and returns false if code has syntax errors
+func (r *Repl) parse(code string) (*ast.Program, bool) {
+	l := lexer.New(code)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if len(l.Errors()) == 0 && len(p.Errors()) == 0 {
+		return program, true
+	}
+
+	for _, e := range l.Errors() {
+		fmt.Fprintf(r.out, "lex error: %d:%d: %s\n", e.Pos.Line, e.Pos.Offset+1, e.Message)
+	}
+	for _, e := range p.Errors() {
+		fmt.Fprintf(r.out, "parse error: %d:%d: %s\n", e.Pos.Line, e.Pos.Offset+1, e.Message)
+	}
+
+	return nil, false
+}
+
+func (r *Repl) printEnv() {
+	vars := r.env.Vars()
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(r.out, "%s = %s\n", name, vars[name].ToString())
+	}
+}
+
+func printTokens(out io.Writer, code string) {
+	l := lexer.New(code)
+	for tok, pos := l.NextToken(); tok.Type != token.EOF; tok, pos = l.NextToken() {
+		fmt.Fprintf(out, "%d:%d\t%s\t%q\n", pos.Line, pos.Offset+1, tok.Type, tok.Literal)
+	}
+}
@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // lines of output without prompts
+	}{
+		{"x = 5\nx * 2", []string{"10"}},
+		{"f = fn(a) {\n\ta + 1\n}\nf(1)", []string{"2"}},
+		{"1 + true", []string{"runtime error: 1:1: type mismatch: INTEGER + BOOLEAN"}},
+		{"x = 1\ny = [1, 2]\n:env", []string{"x = 1", "y = [1, 2]"}},
+		{"1 +", []string{"parse error: 1:4: prefix parse function for EOF not found"}},
+		{":tokens x = 1", []string{"1:1\tIDENT\t\"x\"", "1:3\t=\t\"=\"", "1:5\tINT\t\"1\""}},
+		{"1\n2\n:history", []string{"1", "2", "1: 1", "2: 2"}},
+		// input from history is printed and run again, it is added to history
+		{"x = 1\nx += 1\n:history 2\n:history", []string{"x += 1", "1: x = 1", "2: x += 1", "3: x += 1"}},
+		{"x = 1\nx += 1\n:history 2\nx", []string{"x += 1", "3"}},
+		{"1\n:history 5\n:history a", []string{"1", "no input in history: 5", "no input in history: a"}},
+		{":quit\n1", []string{}},
+		{":foo", []string{"unknown command: :foo (see :help)"}},
+		{"m = {}\nm.self = m\nm\n:env", []string{"[self: [...]]", "m = [self: [...]]"}},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		New(strings.NewReader(test.input), &out).Start()
+
+		got := output(out.String())
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("wrong output in test: %q\ngot:\n%s\nexpected:\n%s", test.input, strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+		}
+	}
+}
+
+func TestOpenBrackets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"x = 1", 0},
+		{"f = fn(a) {", 1},
+		{"if (x) { [1, 2", 2},
+		{"}", -1},
+	}
+
+	for _, test := range tests {
+		if got := openBrackets(test.input); got != test.expected {
+			t.Errorf("wrong number of open brackets. got: %d expected: %d in test: %q", got, test.expected, test.input)
+		}
+	}
+}
+
+// removes prompts and empty lines
+func output(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		for strings.HasPrefix(line, PROMPT) || strings.HasPrefix(line, CONT_PROMPT) {
+			line = line[len(PROMPT):]
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
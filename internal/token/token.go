@@ -16,17 +16,31 @@ const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
-	IDENT  = "IDENT"  // x, t, add
-	INT    = "INT"    // 123
-	STRING = "STRING" // "abcde"
-
-	ASSIGN        = "="
-	PLUS          = "+"
-	MINUS         = "-"
-	STAR          = "*"
-	SLASH         = "/"
-	EXCLAMINATION = "!"
-	PERCENT       = "%"
+	IDENT   = "IDENT"   // x, t, add
+	INT     = "INT"     // 123
+	FLOAT   = "FLOAT"   // 3.14
+	DECIMAL = "DECIMAL" // 19.99d
+	STRING  = "STRING"  // "abcde"
+
+	// template `a ${x} b ${y} c` is split into TEMPLATE_HEAD "a ", tokens of x,
+	// TEMPLATE_MIDDLE " b ", tokens of y and TEMPLATE_TAIL " c"
+	TEMPLATE        = "TEMPLATE"        // `abc`, template without expressions
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"   // text from opening backtick to ${
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE" // text from } to ${
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"   // text from } to closing backtick
+
+	ASSIGN         = "="
+	PLUS_ASSIGN    = "+="
+	MINUS_ASSIGN   = "-="
+	STAR_ASSIGN    = "*="
+	SLASH_ASSIGN   = "/="
+	PERCENT_ASSIGN = "%="
+	PLUS           = "+"
+	MINUS          = "-"
+	STAR           = "*"
+	SLASH          = "/"
+	EXCLAMINATION  = "!"
+	PERCENT        = "%"
 
 	EQ  = "=="
 	NEQ = "!="
@@ -38,9 +52,14 @@ const (
 	LAND = "&&"
 	LOR  = "||"
 
+	NULLISH           = "??"
+	QUESTION_DOT      = "?."
+	QUESTION_LBRACKET = "?["
+
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
 
 	LPAR     = "("
 	RPAR     = ")"
@@ -56,6 +75,15 @@ const (
 	FALSE  = "FALSE"
 	FUNC   = "FUNCTION"
 	RETURN = "RETURN"
+	NULL   = "NULL"
+	LET    = "LET"
+	CONST  = "CONST"
+
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 var keywords = map[string]TokenType{
@@ -65,6 +93,15 @@ var keywords = map[string]TokenType{
 	"false":  FALSE,
 	"fn":     FUNC,
 	"return": RETURN,
+	"null":   NULL,
+	"let":    LET,
+	"const":  CONST,
+
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,50 @@
+package vm
+
+import "github.com/botscubes/bql/internal/object"
+
+const ITERATOR_OBJ = "ITERATOR"
+
+// state of for loop, lives in stack while loop is executed
+type iterator struct {
+	isHashMap bool
+	keys      []object.Object // nil for array
+	values    []object.Object
+	pos       int
+}
+
+func (it *iterator) Type() object.ObjectType { return ITERATOR_OBJ }
+func (it *iterator) ToString() string        { return "iterator" }
+
+func newIterator(obj object.Object) (*iterator, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		// the array can be changed in the body (push), iterate over elements that were before the loop
+		return &iterator{values: obj.Elements}, nil
+	case *object.HashMap:
+		it := &iterator{isHashMap: true}
+		for _, pair := range obj.Ordered() {
+			it.keys = append(it.keys, pair.Key)
+			it.values = append(it.values, pair.Value)
+		}
+
+		return it, nil
+	default:
+		return nil, object.NewError("iteration not supported: %s", obj.Type())
+	}
+}
+
+// returns key (index of array) and value, false if there are no more elements
+func (it *iterator) next() (object.Object, object.Object, bool) {
+	if it.pos >= len(it.values) {
+		return nil, nil, false
+	}
+
+	pos := it.pos
+	it.pos++
+
+	if it.isHashMap {
+		return it.keys[pos], it.values[pos], true
+	}
+
+	return &object.Integer{Value: int64(pos)}, it.values[pos], true
+}
@@ -0,0 +1,648 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/botscubes/bql/internal/compiler"
+	"github.com/botscubes/bql/internal/object"
+)
+
+const (
+	StackSize = 1 << 20 // maximum number of values in stack
+
+	ctxCheckInterval = 1024 // number of instructions between checks of context
+)
+
+var (
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+	NULL  = object.NULL
+)
+
+var conditionNames = map[byte]string{
+	compiler.CondIf:    "if",
+	compiler.CondWhile: "while",
+}
+
+type frame struct {
+	fn    *object.CompiledFunction
+	scope *object.Scope // nil for top level code
+	ip    int
+	bp    int // position of called function in stack
+}
+
+type VM struct {
+	ctx    context.Context
+	limits object.Limits
+
+	constants []object.Object
+	globals   []object.Object
+	names     []string // names of globals
+	consts    []bool   // globals declared by const
+
+	stack []object.Object
+	sp    int // next free slot
+
+	frames []*frame
+
+	lastPopped object.Object
+
+	steps    int
+	limitErr *object.LimitError
+}
+
+// ctx - execution is stopped when ctx is done (deadline, cancel)
+func New(ctx context.Context, limits object.Limits) *VM {
+	return &VM{
+		ctx:    ctx,
+		limits: limits,
+	}
+}
+
+// returns *object.LimitError if execution was stopped by limit, otherwise nil
+func (vm *VM) Err() error {
+	if vm.limitErr != nil {
+		return vm.limitErr
+	}
+
+	return nil
+}
+
+// runs bytecode, global variables are taken from env and their final values are stored back to env
+// (as evaluator does with top level variables).
+// Returns the same result as evaluator.Eval of program: value of last statement,
+// returned value or *object.Error
+func (vm *VM) Run(bc *compiler.Bytecode, env *object.Env) object.Object {
+	vm.constants = bc.Constants
+	vm.names = bc.GlobalNames
+	vm.globals = make([]object.Object, len(bc.GlobalNames))
+	vm.consts = make([]bool, len(bc.GlobalNames))
+	for id, name := range bc.GlobalNames {
+		if val, ok := env.Get(name); ok {
+			vm.globals[id] = val
+		}
+	}
+
+	vm.stack = make([]object.Object, 64)
+	vm.sp = 0
+	vm.frames = []*frame{{fn: bc.Main}}
+	vm.lastPopped = nil
+
+	result, err := vm.run(0)
+
+	for id, name := range bc.GlobalNames {
+		if val := vm.globals[id]; val != nil {
+			env.Set(name, val)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+func (vm *VM) frame() *frame {
+	return vm.frames[len(vm.frames)-1]
+}
+
+func (vm *VM) limitExceeded(err *object.LimitError) *object.Error {
+	vm.limitErr = err
+	return object.NewError("%s", err.Error())
+}
+
+func (vm *VM) step() *object.Error {
+	vm.steps++
+	if vm.limits.MaxSteps > 0 && vm.steps > vm.limits.MaxSteps {
+		return vm.limitExceeded(&object.LimitError{Limit: object.STEPS_LIMIT, Max: vm.limits.MaxSteps})
+	}
+
+	if vm.steps%ctxCheckInterval == 0 {
+		select {
+		case <-vm.ctx.Done():
+			return vm.limitExceeded(&object.LimitError{Limit: object.TIMEOUT_LIMIT, Err: vm.ctx.Err()})
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (vm *VM) checkAlloc(obj object.Object) (object.Object, *object.Error) {
+	if err := vm.limits.CheckAlloc(obj); err != nil {
+		return nil, vm.limitExceeded(err)
+	}
+
+	return obj, nil
+}
+
+func (vm *VM) push(obj object.Object) *object.Error {
+	if vm.sp >= len(vm.stack) {
+		if len(vm.stack) >= StackSize {
+			return object.NewError("stack overflow")
+		}
+
+		vm.stack = append(vm.stack, make([]object.Object, len(vm.stack))...)
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	vm.sp--
+	obj := vm.stack[vm.sp]
+	vm.stack[vm.sp] = nil
+	return obj
+}
+
+// executes instructions until frame at position base returns or top level code ends.
+// Error stops execution and gets position of current instruction
+func (vm *VM) run(base int) (object.Object, *object.Error) {
+	for {
+		f := vm.frame()
+		if f.ip >= len(f.fn.Instructions) {
+			// end of top level code
+			return vm.lastPopped, nil
+		}
+
+		ip := f.ip
+		result, err := vm.exec(f, base)
+		if err != nil {
+			if err.Pos.Line == 0 {
+				err.Pos = f.fn.PosAt(ip)
+			}
+
+			return nil, err
+		}
+
+		if result != nil {
+			return result, nil
+		}
+	}
+}
+
+// executes one instruction, returns not nil result if frame at position base returned
+func (vm *VM) exec(f *frame, base int) (object.Object, *object.Error) {
+	if err := vm.step(); err != nil {
+		return nil, err
+	}
+
+	ins := f.fn.Instructions
+	op := compiler.Opcode(ins[f.ip])
+	f.ip++
+
+	switch op {
+	case compiler.OpConstant:
+		id := vm.readUint16(f)
+		return nil, vm.push(vm.constants[id])
+
+	case compiler.OpNull:
+		return nil, vm.push(NULL)
+
+	case compiler.OpNil:
+		return nil, vm.push(nil)
+
+	case compiler.OpTrue:
+		return nil, vm.push(TRUE)
+
+	case compiler.OpFalse:
+		return nil, vm.push(FALSE)
+
+	case compiler.OpPop:
+		vm.lastPopped = vm.pop()
+
+	case compiler.OpDup:
+		n := int(vm.readUint8(f))
+		for _, obj := range vm.stack[vm.sp-n : vm.sp] {
+			if err := vm.push(obj); err != nil {
+				return nil, err
+			}
+		}
+
+	case compiler.OpPrefix:
+		op := compiler.Operators[vm.readUint8(f)]
+		res := vm.limits.PrefixOp(op, vm.pop())
+		if err, ok := res.(*object.Error); ok {
+			return nil, err
+		}
+
+		return nil, vm.push(res)
+
+	case compiler.OpInfix:
+		op := compiler.Operators[vm.readUint8(f)]
+		right := vm.pop()
+		left := vm.pop()
+		if err := vm.limits.CheckInfixAlloc(op, left, right); err != nil {
+			return nil, vm.limitExceeded(err)
+		}
+
+		return nil, vm.pushResult(vm.limits.InfixOp(op, left, right))
+
+	case compiler.OpIndex:
+		index := vm.pop()
+		left := vm.pop()
+		return nil, vm.pushResult(object.IndexOp(left, index))
+
+	case compiler.OpSlice:
+		end := vm.pop()
+		start := vm.pop()
+		left := vm.pop()
+		return nil, vm.pushResult(object.SliceOp(left, start, end))
+
+	case compiler.OpMember:
+		name := vm.constants[vm.readUint16(f)].(*object.String).Value
+		return nil, vm.pushResult(object.MemberOp(vm.pop(), name))
+
+	case compiler.OpSetIndex:
+		val := vm.pop()
+		index := vm.pop()
+		left := vm.pop()
+		if err := object.SetIndexOp(left, index, val); err != nil {
+			return nil, err.(*object.Error)
+		}
+
+		_, err := vm.checkAlloc(left)
+		return nil, err
+
+	case compiler.OpSetMember:
+		name := vm.constants[vm.readUint16(f)].(*object.String).Value
+		val := vm.pop()
+		left := vm.pop()
+		if err := object.SetMemberOp(left, name, val); err != nil {
+			return nil, err.(*object.Error)
+		}
+
+		_, err := vm.checkAlloc(left)
+		return nil, err
+
+	case compiler.OpArray:
+		n := int(vm.readUint16(f))
+		elements := make([]object.Object, n)
+		copy(elements, vm.stack[vm.sp-n:vm.sp])
+		vm.drop(n)
+
+		return nil, vm.pushResult(&object.Array{Elements: elements})
+
+	case compiler.OpHash:
+		n := int(vm.readUint16(f))
+		hashMap, err := vm.buildHashMap(vm.stack[vm.sp-2*n : vm.sp])
+		if err != nil {
+			return nil, err
+		}
+		vm.drop(2 * n)
+
+		return nil, vm.pushResult(hashMap)
+
+	case compiler.OpTemplate:
+		n := int(vm.readUint16(f))
+		if err := vm.limits.CheckTemplateAlloc(vm.stack[vm.sp-n : vm.sp]); err != nil {
+			return nil, vm.limitExceeded(err)
+		}
+
+		str := object.TemplateOp(vm.stack[vm.sp-n : vm.sp])
+		vm.drop(n)
+
+		return nil, vm.pushResult(str)
+
+	case compiler.OpJump:
+		f.ip = int(compiler.ReadUint16(ins[f.ip:]))
+
+	case compiler.OpJumpIfFalse:
+		target := int(vm.readUint16(f))
+		kind := vm.readUint8(f)
+
+		switch vm.pop() {
+		case TRUE:
+		case FALSE:
+			f.ip = target
+		default:
+			return nil, object.NewError("non boolean condition in %s statement", conditionNames[kind])
+		}
+
+	case compiler.OpJumpIfNull:
+		target := int(vm.readUint16(f))
+		if vm.stack[vm.sp-1] == NULL {
+			f.ip = target
+		}
+
+	case compiler.OpCoalesce:
+		target := int(vm.readUint16(f))
+		if vm.stack[vm.sp-1] != NULL {
+			f.ip = target
+		} else {
+			vm.pop()
+		}
+
+	case compiler.OpGetGlobal:
+		return nil, vm.pushGlobal(vm.readUint16(f))
+
+	case compiler.OpSetGlobal:
+		return nil, vm.setGlobal(vm.readUint16(f))
+
+	case compiler.OpGetLocal:
+		id := vm.readUint16(f)
+		return nil, vm.pushVar(f.scope, int(id))
+
+	case compiler.OpSetLocal:
+		return nil, vm.setVar(f.scope, int(vm.readUint16(f)))
+
+	case compiler.OpGetOuter:
+		depth := vm.readUint8(f)
+		id := vm.readUint16(f)
+
+		scope := f.scope
+		for i := byte(0); i < depth; i++ {
+			scope = scope.Outer
+		}
+
+		return nil, vm.pushVar(scope, int(id))
+
+	case compiler.OpSetOuter:
+		depth := vm.readUint8(f)
+		id := vm.readUint16(f)
+
+		scope := f.scope
+		for i := byte(0); i < depth; i++ {
+			scope = scope.Outer
+		}
+		return nil, vm.setVar(scope, int(id))
+
+	case compiler.OpGetDynamic:
+		scope, id, global := vm.readDynamic(f)
+		if val := scope.Vars[id]; val != nil {
+			return nil, vm.push(val)
+		}
+
+		return nil, vm.pushGlobal(global)
+
+	case compiler.OpSetDynamic:
+		scope, id, global := vm.readDynamic(f)
+		if scope.Vars[id] == nil && vm.globals[global] != nil {
+			return nil, vm.setGlobal(global)
+		}
+		return nil, vm.setVar(scope, int(id))
+
+	case compiler.OpDeclareGlobal:
+		id := vm.readUint16(f)
+		vm.consts[id] = vm.readUint8(f) == 1
+		vm.globals[id] = vm.pop()
+
+	case compiler.OpDeclareLocal:
+		id := int(vm.readUint16(f))
+		f.scope.Declare(id, vm.readUint8(f) == 1)
+		f.scope.Vars[id] = vm.pop()
+
+	case compiler.OpEnterScope:
+		block := vm.constants[vm.readUint16(f)].(*object.BlockScope)
+		f.scope = &object.Scope{
+			Vars:  make([]object.Object, len(block.Names)),
+			Names: block.Names,
+			Outer: f.scope,
+		}
+
+	case compiler.OpLeaveScope:
+		f.scope = f.scope.Outer
+
+	case compiler.OpClosure:
+		fn := vm.constants[vm.readUint16(f)].(*object.CompiledFunction)
+		return nil, vm.push(&object.Closure{Fn: fn, Scope: f.scope})
+
+	case compiler.OpCall:
+		argc := int(vm.readUint8(f))
+		return nil, vm.call(argc)
+
+	case compiler.OpReturnValue:
+		val := vm.pop()
+		if val == nil {
+			val = NULL
+		}
+
+		vm.frames = vm.frames[:len(vm.frames)-1]
+		vm.drop(vm.sp - f.bp)
+
+		if len(vm.frames) == base {
+			return val, nil
+		}
+
+		return nil, vm.push(val)
+
+	case compiler.OpIterInit:
+		it, err := newIterator(vm.pop())
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, vm.push(it)
+
+	case compiler.OpIterNext:
+		target := int(vm.readUint16(f))
+		mode := vm.readUint8(f)
+
+		it := vm.stack[vm.sp-1].(*iterator)
+		key, value, ok := it.next()
+		if !ok {
+			f.ip = target
+			return nil, nil
+		}
+
+		if mode == compiler.IterValue {
+			if it.isHashMap {
+				return nil, vm.push(key)
+			}
+
+			return nil, vm.push(value)
+		}
+
+		if err := vm.push(key); err != nil {
+			return nil, err
+		}
+
+		return nil, vm.push(value)
+
+	default:
+		return nil, object.NewError("unknown opcode: %d", op)
+	}
+
+	return nil, nil
+}
+
+func (vm *VM) readUint16(f *frame) uint16 {
+	v := compiler.ReadUint16(f.fn.Instructions[f.ip:])
+	f.ip += 2
+	return v
+}
+
+func (vm *VM) readUint8(f *frame) byte {
+	v := f.fn.Instructions[f.ip]
+	f.ip++
+	return v
+}
+
+// removes n values from top of stack
+func (vm *VM) drop(n int) {
+	for i := 0; i < n; i++ {
+		vm.sp--
+		vm.stack[vm.sp] = nil
+	}
+}
+
+// pushes result of operation, errors and exceeding of allocation limit stop execution
+func (vm *VM) pushResult(res object.Object) *object.Error {
+	if err, ok := res.(*object.Error); ok {
+		return err
+	}
+
+	res, err := vm.checkAlloc(res)
+	if err != nil {
+		return err
+	}
+
+	return vm.push(res)
+}
+
+// pushes global variable or builtin with the same name
+func (vm *VM) pushGlobal(id uint16) *object.Error {
+	val := vm.globals[id]
+	if val == nil {
+		name := vm.names[id]
+		builtin, ok := object.Builtins[name]
+		if !ok {
+			return object.NewError("identifier not found: " + name)
+		}
+		val = builtin
+	}
+
+	return vm.push(val)
+}
+
+// sets global variable to value on top of stack
+func (vm *VM) setGlobal(id uint16) *object.Error {
+	if vm.consts[id] {
+		return object.NewError("assignment to constant: %s", vm.names[id])
+	}
+
+	vm.globals[id] = vm.pop()
+	return nil
+}
+
+// sets local variable to value on top of stack
+func (vm *VM) setVar(scope *object.Scope, id int) *object.Error {
+	if scope.IsConst(id) {
+		return object.NewError("assignment to constant: %s", scope.Names[id])
+	}
+
+	scope.Vars[id] = vm.pop()
+	return nil
+}
+
+// reads operands of OpGetDynamic and OpSetDynamic, returns scope of local variable
+func (vm *VM) readDynamic(f *frame) (*object.Scope, uint16, uint16) {
+	depth := vm.readUint8(f)
+	id := vm.readUint16(f)
+	global := vm.readUint16(f)
+
+	scope := f.scope
+	for i := byte(0); i < depth; i++ {
+		scope = scope.Outer
+	}
+
+	return scope, id, global
+}
+
+func (vm *VM) pushVar(scope *object.Scope, id int) *object.Error {
+	val := scope.Vars[id]
+	if val == nil {
+		return object.NewError("identifier not found: " + scope.Names[id])
+	}
+
+	return vm.push(val)
+}
+
+func (vm *VM) buildHashMap(values []object.Object) (object.Object, *object.Error) {
+	hashMap := object.NewHashMap(len(values) / 2)
+
+	for i := 0; i < len(values); i += 2 {
+		key, value := values[i], values[i+1]
+
+		if _, ok := key.(object.Hashable); !ok {
+			return nil, object.NewError("unusable as hash key: %s", key.Type())
+		}
+
+		hashMap.Set(key, value)
+	}
+
+	return hashMap, nil
+}
+
+// calls function placed in stack before argc arguments
+func (vm *VM) call(argc int) *object.Error {
+	bp := vm.sp - argc - 1
+	args := vm.stack[bp+1 : vm.sp]
+
+	switch fn := vm.stack[bp].(type) {
+	case *object.Closure:
+		if argc != fn.Fn.NumParameters {
+			return object.NewError("wrong number of arguments: %d want: %d", argc, fn.Fn.NumParameters)
+		}
+
+		if maxDepth := vm.limits.CallDepth(); maxDepth > 0 && len(vm.frames)-1 >= maxDepth {
+			return vm.limitExceeded(&object.LimitError{Limit: object.CALL_DEPTH_LIMIT, Max: maxDepth})
+		}
+
+		scope := &object.Scope{
+			Vars:  make([]object.Object, fn.Fn.NumLocals),
+			Names: fn.Fn.LocalNames,
+			Outer: fn.Scope,
+		}
+		copy(scope.Vars, args)
+
+		vm.frames = append(vm.frames, &frame{fn: fn.Fn, scope: scope, bp: bp})
+		return nil
+	case *object.Builtin:
+		if err := vm.limits.CheckCallAlloc(fn, args); err != nil {
+			return vm.limitExceeded(err)
+		}
+
+		res := fn.Call(vm.callFunction, args...)
+		vm.drop(argc + 1)
+
+		return vm.pushResult(res)
+	default:
+		return object.NewError("call not a function: %s", fn.Type())
+	}
+}
+
+// calls function from builtin, function is executed until it returns
+func (vm *VM) callFunction(fn object.Object, args ...object.Object) object.Object {
+	base := len(vm.frames)
+	sp := vm.sp
+
+	for _, obj := range append([]object.Object{fn}, args...) {
+		if err := vm.push(obj); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.call(len(args)); err != nil {
+		vm.drop(vm.sp - sp)
+		return err
+	}
+
+	if len(vm.frames) == base {
+		// builtin pushed its result
+		return vm.pop()
+	}
+
+	result, err := vm.run(base)
+	if err != nil {
+		vm.frames = vm.frames[:base]
+		vm.drop(vm.sp - sp)
+		return err
+	}
+
+	return result
+}
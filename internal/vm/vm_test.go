@@ -0,0 +1,115 @@
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/botscubes/bql/internal/compiler"
+	"github.com/botscubes/bql/internal/lexer"
+	"github.com/botscubes/bql/internal/object"
+	"github.com/botscubes/bql/internal/parser"
+)
+
+func TestScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"x = 1; f = fn() { x = 2 }; f(); x", 2},
+		{"x = 1; f = fn() { let x = 2 }; f(); x", 1},
+		{"f = fn() { y = 2 }; y = 1; f(); y", 2},
+		{"x = 1; if (true) { let x = 2; x = 3 }; x", 1},
+		{"s = 0; for (i in [1, 2, 3]) { let d = i * 2; if (d > 4) { break }; s = s + d }; s", 6},
+		{"s = 0; for (i in [1, 2, 3]) { let d = i; if (d == 2) { continue }; s = s + d }; s", 4},
+		{"f = fn() { let a = 1; g = fn() { a = a + 1 }; g(); g(); a }; f()", 3},
+		{"fs = []; for (i in [1, 2]) { push(fs, fn() { i }) }; fs[0]() * 10 + fs[1]()", 12},
+		{"x = 1; f = fn() { x }; x = 3; f()", 3},
+		{"f = fn() { a = 1; g = fn() { a }; a = 5; g() }; f()", 5},
+		{"f = fn(n) { fact = fn(n) { if (n == 0) { return 1 }; n * fact(n - 1) }; fact(n) }; f(5)", 120},
+		{"adder = fn(x) { fn(y) { fn(z) { x + y + z } } }; adder(1)(2)(3)", 6},
+		{"f = fn() { s = 0; for (x in [1, 2, 3]) { s = s + x }; s }; f()", 6},
+		{"f = fn() { i = 0; while (true) { i = i + 1; if (i == 5) { return i } } }; f()", 5},
+		{"len = fn(x) { 42 }; len([1])", 42},
+		{"len([1])", 1},
+	}
+
+	for _, test := range tests {
+		ev := run(t, test.input, object.NewEnv())
+		res, ok := ev.(*object.Integer)
+		if !ok {
+			t.Errorf("obj not Integer got: %+v in test: %s", ev, test.input)
+			continue
+		}
+
+		if res.Value != test.expected {
+			t.Errorf("obj wrong value. got: %d expected: %d in test: %s", res.Value, test.expected, test.input)
+		}
+	}
+}
+
+func TestGlobalsFromEnv(t *testing.T) {
+	env := object.NewEnv()
+	env.Set("a", &object.Integer{Value: 2})
+	env.Set("len", &object.Integer{Value: 3})
+
+	ev := run(t, "a * len", env)
+	res, ok := ev.(*object.Integer)
+	if !ok || res.Value != 6 {
+		t.Errorf("wrong result. got: %+v expected: 6", ev)
+	}
+}
+
+func TestGlobalsToEnv(t *testing.T) {
+	env := object.NewEnv()
+	env.Set("count", &object.Integer{Value: 1})
+	env.Set("cart", &object.Array{})
+
+	run(t, "count = count + 1; push(cart, 5); total = 10; f = fn() { local = 1 }; f()", env)
+
+	tests := map[string]string{
+		"count": "2",
+		"cart":  "[5]",
+		"total": "10",
+	}
+
+	for name, expected := range tests {
+		val, ok := env.Get(name)
+		if !ok {
+			t.Errorf("variable %s not found in env", name)
+			continue
+		}
+
+		if val.ToString() != expected {
+			t.Errorf("wrong value of %s. got: %s expected: %s", name, val.ToString(), expected)
+		}
+	}
+
+	if _, ok := env.Get("local"); ok {
+		t.Errorf("local variable of function stored in env")
+	}
+}
+
+func TestNilResult(t *testing.T) {
+	for _, input := range []string{"x = 1", "while (false) { }", ""} {
+		if ev := run(t, input, object.NewEnv()); ev != nil {
+			t.Errorf("non nil result: %+v in test: %q", ev, input)
+		}
+	}
+}
+
+func run(t *testing.T, input string, env *object.Env) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	bc, err := compiler.Compile(program)
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+
+	return New(context.Background(), object.Limits{}).Run(bc, env)
+}